@@ -2,13 +2,16 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey/token"
+	"strings"
 )
 
 // Node interface
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 // Statement interface
@@ -44,6 +47,14 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// Pos implementation of the program node
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 // LetStatement struct
 type LetStatement struct {
 	Token token.Token // token.LET token
@@ -56,6 +67,11 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+// Pos implementation for LetStatement
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
 // statementNode implementation for Let
 func (ls *LetStatement) statementNode() {}
 
@@ -83,6 +99,11 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos implementation for Identifier
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
 // expressionNode implementation
 func (i *Identifier) expressionNode() {}
 
@@ -104,12 +125,17 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+// Pos implementation of ReturnStatements
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
 // String implementation of ReturnStatemens
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
-	out.WriteString(rs.TokenLiteral() + " ")
+	out.WriteString(rs.TokenLiteral())
 	if rs.ReturnValue != nil {
-		out.WriteString(rs.ReturnValue.String())
+		out.WriteString(" " + rs.ReturnValue.String())
 	}
 	out.WriteString(";")
 	return out.String()
@@ -128,6 +154,11 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+// Pos implementation for ExpressionStatement
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
 // String implementation for ExpressionStatements
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
@@ -140,6 +171,7 @@ func (es *ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token // token.INT token
 	Value int64
+	Big   *big.Int // set instead of Value when the literal overflows int64
 }
 
 // TokenLiteral implementation for Identified
@@ -147,6 +179,11 @@ func (i *IntegerLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos implementation for IntegerLiteral
+func (i *IntegerLiteral) Pos() token.Position {
+	return i.Token.Pos
+}
+
 // expressionNode implementation
 func (i *IntegerLiteral) expressionNode() {}
 
@@ -155,6 +192,54 @@ func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
 }
 
+// FloatLiteral type
+type FloatLiteral struct {
+	Token token.Token // token.FLOAT token
+	Value float64
+}
+
+// TokenLiteral implementation for FloatLiteral
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+// Pos implementation for FloatLiteral
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+// expressionNode implementation
+func (fl *FloatLiteral) expressionNode() {}
+
+// String implementation for FloatLiteral
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+// StringLiteral type
+type StringLiteral struct {
+	Token token.Token // token.STRING token
+	Value string
+}
+
+// TokenLiteral implementation for StringLiteral
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+// Pos implementation for StringLiteral
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+// expressionNode implementation
+func (sl *StringLiteral) expressionNode() {}
+
+// String implementation for StringLiteral
+func (sl *StringLiteral) String() string {
+	return sl.Token.Literal
+}
+
 // PrefixExpression type
 type PrefixExpression struct {
 	Token    token.Token
@@ -167,6 +252,11 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+// Pos implementation for PrefixExpression
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
 // String implementation for PrefixExpression
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
@@ -192,6 +282,11 @@ func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+// Pos implementation for InfixExpression
+func (ie *InfixExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
 // String implementation for InfixExpression
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
@@ -216,9 +311,241 @@ func (be *Boolean) TokenLiteral() string {
 	return be.Token.Literal
 }
 
+// Pos implementation for Boolean
+func (be *Boolean) Pos() token.Position {
+	return be.Token.Pos
+}
+
 // String implementation for Boolean
 func (be *Boolean) String() string {
 	return be.Token.Literal
 }
 
 func (ie *Boolean) expressionNode() {}
+
+// BlockStatement type
+type BlockStatement struct {
+	Token      token.Token // the { token
+	Statements []Statement
+}
+
+func (bs *BlockStatement) statementNode() {}
+
+// TokenLiteral implementation for BlockStatement
+func (bs *BlockStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+// Pos implementation for BlockStatement
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+// String implementation for BlockStatement
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+	return out.String()
+}
+
+// IfExpression type
+type IfExpression struct {
+	Token       token.Token // the if token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode() {}
+
+// TokenLiteral implementation for IfExpression
+func (ie *IfExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+// Pos implementation for IfExpression
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+// String implementation for IfExpression
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("if")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Consequence.String())
+	if ie.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ie.Alternative.String())
+	}
+	return out.String()
+}
+
+// FunctionLiteral type
+type FunctionLiteral struct {
+	Token      token.Token // the fn token
+	Name       string      // the name bound by let, empty for anonymous functions
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode() {}
+
+// TokenLiteral implementation for FunctionLiteral
+func (fl *FunctionLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+// Pos implementation for FunctionLiteral
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+// String implementation for FunctionLiteral
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+	return out.String()
+}
+
+// CallExpression type
+type CallExpression struct {
+	Token     token.Token // the ( token
+	Function  Expression  // Identifier or FunctionLiteral
+	Arguments []Expression
+}
+
+func (ce *CallExpression) expressionNode() {}
+
+// TokenLiteral implementation for CallExpression
+func (ce *CallExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+// Pos implementation for CallExpression, the position of the callee
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Function.Pos()
+}
+
+// String implementation for CallExpression
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// ArrayLiteral type
+type ArrayLiteral struct {
+	Token    token.Token // the [ token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+// TokenLiteral implementation for ArrayLiteral
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+// Pos implementation for ArrayLiteral
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+// String implementation for ArrayLiteral
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+// IndexExpression type
+type IndexExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode() {}
+
+// TokenLiteral implementation for IndexExpression
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+// Pos implementation for IndexExpression
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+// String implementation for IndexExpression
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+// HashPair is a single key: value entry of a HashLiteral
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral type
+type HashLiteral struct {
+	Token token.Token // the { token
+	Pairs []HashPair  // in source order
+}
+
+func (hl *HashLiteral) expressionNode() {}
+
+// TokenLiteral implementation for HashLiteral
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+
+// Pos implementation for HashLiteral
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
+// String implementation for HashLiteral
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
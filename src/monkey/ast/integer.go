@@ -0,0 +1,88 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ParseInteger returns the value of an integer literal, which is decimal
+// unless it has a 0x, 0o or 0b prefix and may use _ separators. Values
+// beyond int64 are returned as a *big.Int instead.
+func ParseInteger(literal string) (int64, *big.Int, error) {
+	digits, base, err := splitIntegerLiteral(literal)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i64, err := strconv.ParseInt(digits, base, 64)
+	if err == nil {
+		return i64, nil, nil
+	}
+	value, _ := new(big.Int).SetString(digits, base)
+	return 0, value, nil
+}
+
+var baseNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	10: "decimal",
+	16: "hexadecimal",
+}
+
+// splitIntegerLiteral checks the digits and _ separators of an integer
+// literal and returns its digits without prefix and separators, along
+// with its base
+func splitIntegerLiteral(literal string) (string, int, error) {
+	base, body := 10, literal
+	if len(literal) >= 2 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			base, body = 16, literal[2:]
+		case 'o', 'O':
+			base, body = 8, literal[2:]
+		case 'b', 'B':
+			base, body = 2, literal[2:]
+		}
+	}
+	prefixed := base != 10
+
+	var digits strings.Builder
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		if ch == '_' {
+			// like Go, a separator may follow the base prefix or a digit
+			if (i == 0 && !prefixed) || (i > 0 && body[i-1] == '_') {
+				return "", 0, fmt.Errorf("'_' must separate successive digits")
+			}
+			continue
+		}
+		if digitValue(ch) >= base {
+			return "", 0, fmt.Errorf("invalid digit %q in %s literal", ch, baseNames[base])
+		}
+		digits.WriteByte(ch)
+	}
+
+	if digits.Len() == 0 {
+		return "", 0, fmt.Errorf("%s literal has no digits", baseNames[base])
+	}
+	if body[len(body)-1] == '_' {
+		return "", 0, fmt.Errorf("'_' must separate successive digits")
+	}
+	return digits.String(), base, nil
+}
+
+// digitValue returns the value of a hexadecimal digit, or 16 for any other
+// character
+func digitValue(ch byte) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
+	}
+	return 16
+}
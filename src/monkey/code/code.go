@@ -0,0 +1,254 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions is a flat stream of encoded opcodes and their operands
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+		i += 1 + read
+	}
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	if len(operands) != len(def.OperandWidths) {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d",
+			len(operands), len(def.OperandWidths))
+	}
+
+	var out bytes.Buffer
+	out.WriteString(def.Name)
+	for _, o := range operands {
+		fmt.Fprintf(&out, " %d", o)
+	}
+	return out.String()
+}
+
+// Opcode identifies an instruction
+type Opcode byte
+
+const (
+	// OpConstant pushes the constant at the operand's index in the pool
+	OpConstant Opcode = iota
+	// OpPop discards the top of the stack
+	OpPop
+
+	// OpAdd and the following pop two operands and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessEqual
+	OpGreaterThan
+	OpGreaterEqual
+
+	// OpMinus and the following replace the top of the stack
+	OpMinus
+	OpBang
+	OpBitNot
+
+	// OpTrue pushes true
+	OpTrue
+	// OpFalse pushes false
+	OpFalse
+	// OpNull pushes null
+	OpNull
+
+	// OpJump continues at the operand's offset
+	OpJump
+	// OpJumpNotTruthy pops a value and jumps if it is not truthy
+	OpJumpNotTruthy
+	// OpJumpTruthy pops a value and jumps if it is truthy
+	OpJumpTruthy
+
+	// OpGetGlobal pushes the global binding at the operand's index
+	OpGetGlobal
+	// OpSetGlobal pops a value into the global binding at the operand's index
+	OpSetGlobal
+	// OpGetLocal pushes the local binding at the operand's index
+	OpGetLocal
+	// OpSetLocal pops a value into the local binding at the operand's index
+	OpSetLocal
+	// OpGetBuiltin pushes the builtin at the operand's index
+	OpGetBuiltin
+	// OpGetFree pushes the free variable at the operand's index
+	OpGetFree
+	// OpCurrentClosure pushes the closure being executed, for recursion
+	OpCurrentClosure
+
+	// OpArray builds an array from the operand's number of elements
+	OpArray
+	// OpHash builds a hash from the operand's number of keys and values
+	OpHash
+	// OpIndex pops an index and the value being indexed and pushes the element
+	OpIndex
+
+	// OpCall calls the function below the operand's number of arguments
+	OpCall
+	// OpReturnValue returns the top of the stack from the current function
+	OpReturnValue
+	// OpReturn returns null from the current function
+	OpReturn
+	// OpClosure pushes a closure of the function constant at the first
+	// operand, capturing the second operand's number of free variables
+	OpClosure
+)
+
+// Definition describes an opcode's name and the byte width of each operand
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+// MaxOperand returns the largest value operand i of def can hold
+func (def *Definition) MaxOperand(i int) int {
+	return 1<<(8*uint(def.OperandWidths[i])) - 1
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpBitAnd:       {"OpBitAnd", []int{}},
+	OpBitOr:        {"OpBitOr", []int{}},
+	OpBitXor:       {"OpBitXor", []int{}},
+	OpShiftLeft:    {"OpShiftLeft", []int{}},
+	OpShiftRight:   {"OpShiftRight", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+
+	OpMinus:  {"OpMinus", []int{}},
+	OpBang:   {"OpBang", []int{}},
+	OpBitNot: {"OpBitNot", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJumpTruthy:    {"OpJumpTruthy", []int{2}},
+
+	OpGetGlobal:      {"OpGetGlobal", []int{2}},
+	OpSetGlobal:      {"OpSetGlobal", []int{2}},
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpGetBuiltin:     {"OpGetBuiltin", []int{1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}},
+}
+
+// Lookup returns the Definition of the opcode op
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction, operands are big endian and truncated to
+// their width, see MaxOperand. It returns an empty slice for undefined
+// opcodes.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodes the operands of def from ins, returning them with
+// the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+// ReadUint16 decodes a two byte operand
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 decodes a one byte operand
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
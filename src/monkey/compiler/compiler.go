@@ -0,0 +1,501 @@
+package compiler
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/code"
+	"monkey/object"
+)
+
+// Bytecode is the output of the compiler: the instructions of the top
+// level program and the constants they refer to
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+}
+
+// EmittedInstruction records an instruction's opcode and offset
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope holds the instructions of the function being compiled
+type CompilationScope struct {
+	instructions        code.Instructions
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+}
+
+// Compiler turns an AST into Bytecode
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	// operandErr is the first operand emitted that does not fit its
+	// width, reported by Compile with the position of the node
+	operandErr error
+}
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"<<": code.OpShiftLeft,
+	">>": code.OpShiftRight,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterEqual,
+}
+
+// operandNames tells what the operands of an opcode are, for errors
+var operandNames = map[code.Opcode][]string{
+	code.OpConstant:      {"constant index"},
+	code.OpJump:          {"jump target"},
+	code.OpJumpNotTruthy: {"jump target"},
+	code.OpJumpTruthy:    {"jump target"},
+	code.OpGetGlobal:     {"global index"},
+	code.OpSetGlobal:     {"global index"},
+	code.OpGetLocal:      {"local index"},
+	code.OpSetLocal:      {"local index"},
+	code.OpGetBuiltin:    {"builtin index"},
+	code.OpGetFree:       {"free variable index"},
+	code.OpArray:         {"array size"},
+	code.OpHash:          {"hash size"},
+	code.OpCall:          {"argument count"},
+	code.OpClosure:       {"constant index", "free variable count"},
+}
+
+var prefixOpcodes = map[string]code.Opcode{
+	"-": code.OpMinus,
+	"!": code.OpBang,
+	"~": code.OpBitNot,
+}
+
+// New creates a Compiler. Builtins get their index in object.Builtins.
+func New() *Compiler {
+	symbolTable := NewSymbolTable()
+	for i, def := range object.Builtins() {
+		symbolTable.DefineBuiltin(i, def.Name)
+	}
+	return NewWithState(symbolTable, []object.Object{})
+}
+
+// NewWithState creates a Compiler that continues from the bindings and
+// constants of an earlier compilation, as needed by a REPL
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	mainScope := CompilationScope{instructions: code.Instructions{}}
+	return &Compiler{
+		constants:   constants,
+		symbolTable: s,
+		scopes:      []CompilationScope{mainScope},
+	}
+}
+
+// Compile emits the instructions for node and its children. It fails when
+// an operand, such as a constant index or a jump target, is too large for
+// its encoding.
+func (c *Compiler) Compile(node ast.Node) error {
+	if err := c.compileNode(node); err != nil {
+		return err
+	}
+	if c.operandErr != nil {
+		err := fmt.Errorf("%s: %s", node.Pos(), c.operandErr)
+		c.operandErr = nil
+		return err
+	}
+	return nil
+}
+
+func (c *Compiler) compileNode(node ast.Node) error {
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		c.declareGlobals(node)
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		// the value is compiled first, so that it still sees an earlier
+		// binding of the same name. A global keeps the slot it was
+		// declared with, see declareGlobals.
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		symbol, ok := c.symbolTable.store[node.Name.Value]
+		if !ok || symbol.Scope != GlobalScope {
+			symbol = c.symbolTable.Define(node.Name.Value)
+		}
+		if symbol.Scope == GlobalScope {
+			c.emit(code.OpSetGlobal, symbol.Index)
+		} else {
+			c.emit(code.OpSetLocal, symbol.Index)
+		}
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			c.emit(code.OpReturn)
+			return nil
+		}
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		var integer object.Object = &object.Integer{Value: node.Value}
+		if node.Big != nil {
+			integer = &object.BigInt{Value: node.Big}
+		}
+		c.emit(code.OpConstant, c.addConstant(integer))
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			return fmt.Errorf("%s: undefined variable %s", node.Pos(), node.Value)
+		}
+		c.loadSymbol(symbol)
+	case *ast.PrefixExpression:
+		op, ok := prefixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("%s: unknown operator %s", node.Pos(), node.Operator)
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogicalExpression(node)
+		}
+		op, ok := infixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("%s: unknown operator %s", node.Pos(), node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+	}
+	return nil
+}
+
+// declareGlobals defines the names bound by the top level lets of program
+// before any statement is compiled, so that functions can refer to globals
+// bound after them, as mutually recursive functions do. Names that already
+// resolve, such as builtins, are left alone until their let is compiled, so
+// that the code before it still sees the old binding.
+func (c *Compiler) declareGlobals(program *ast.Program) {
+	if c.symbolTable.Outer != nil {
+		return
+	}
+	for _, s := range program.Statements {
+		let, ok := s.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		if _, ok := c.symbolTable.Resolve(let.Name.Value); !ok {
+			c.symbolTable.Define(let.Name.Value)
+		}
+	}
+}
+
+// Bytecode returns the instructions and constants compiled so far
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+	}
+}
+
+// compileLogicalExpression jumps past the right operand when the left one
+// decides the result, leaving a Boolean like the evaluator does
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	jump := code.OpJumpNotTruthy
+	decided := code.OpFalse
+	undecided := code.OpTrue
+	if node.Operator == "||" {
+		jump, decided, undecided = code.OpJumpTruthy, code.OpTrue, code.OpFalse
+	}
+
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	leftJumpPos := c.emit(jump, 9999)
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	rightJumpPos := c.emit(jump, 9999)
+	c.emit(undecided)
+	endJumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(leftJumpPos, len(c.currentInstructions()))
+	c.changeOperand(rightJumpPos, len(c.currentInstructions()))
+	c.emit(decided)
+	c.changeOperand(endJumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+	if err := c.compileBranch(node.Consequence); err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBranch(node.Alternative); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileBranch compiles a block of an if expression so that it leaves
+// exactly one value, null when it does not end in an expression
+func (c *Compiler) compileBranch(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+	return nil
+}
+
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	c.enterScope()
+
+	if node.Name != "" {
+		c.symbolTable.DefineFunctionName(node.Name)
+	}
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value)
+	}
+
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.NumDefinitions()
+	instructions := c.leaveScope()
+
+	// the enclosing scope pushes the captured values for OpClosure
+	for _, s := range freeSymbols {
+		c.loadSymbol(s)
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Name:          node.Name,
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+	}
+	c.emit(code.OpClosure, c.addConstant(compiledFn), len(freeSymbols))
+	return nil
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// emit appends an instruction to the current scope and returns its offset
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	c.setLastInstruction(op, pos)
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+
+	c.scopes[c.scopeIndex].instructions = c.currentInstructions()[:last.Position]
+	c.scopes[c.scopeIndex].lastInstruction = previous
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+// changeOperand patches the operand of the instruction at opPos, used to
+// fill in jump targets once they are known
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.checkOperands(op, []int{operand})
+	c.replaceInstruction(opPos, code.Make(op, operand))
+}
+
+// checkOperands records an error for the first operand that Make would
+// truncate
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	def, err := code.Lookup(byte(op))
+	if err != nil || c.operandErr != nil {
+		return
+	}
+	for i, o := range operands {
+		if max := def.MaxOperand(i); o > max {
+			c.operandErr = fmt.Errorf("%s %d out of range, the limit is %d", operandNames[op][i], o, max)
+			return
+		}
+	}
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instructions: code.Instructions{}})
+	c.scopeIndex++
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.currentInstructions()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+	return instructions
+}
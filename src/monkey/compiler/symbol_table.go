@@ -0,0 +1,111 @@
+package compiler
+
+// SymbolScope tells where a binding lives at runtime
+type SymbolScope string
+
+const (
+	// GlobalScope for bindings made at the top level
+	GlobalScope SymbolScope = "GLOBAL"
+	// LocalScope for parameters and bindings made inside a function
+	LocalScope SymbolScope = "LOCAL"
+	// BuiltinScope for builtin functions
+	BuiltinScope SymbolScope = "BUILTIN"
+	// FreeScope for locals of an enclosing function captured by a closure
+	FreeScope SymbolScope = "FREE"
+	// FunctionScope for the name of the function being compiled
+	FunctionScope SymbolScope = "FUNCTION"
+)
+
+// Symbol is a resolved binding
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable maps names to symbols for one scope, falling back to its
+// outer table for names it does not define
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	// FreeSymbols holds the symbols of enclosing functions this scope
+	// captures, in the order of their FreeScope indexes
+	FreeSymbols []Symbol
+
+	store          map[string]Symbol
+	numDefinitions int
+}
+
+// NewSymbolTable creates an empty top level SymbolTable
+func NewSymbolTable() *SymbolTable {
+	s := make(map[string]Symbol)
+	return &SymbolTable{store: s}
+}
+
+// NewEnclosedSymbolTable creates an empty SymbolTable for a function
+// nested in outer
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define binds name to the next free slot of this scope
+func (s *SymbolTable) Define(name string) Symbol {
+	symbol := Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+	} else {
+		symbol.Scope = LocalScope
+	}
+
+	s.store[name] = symbol
+	s.numDefinitions++
+	return symbol
+}
+
+// DefineBuiltin binds name to the builtin at index
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// DefineFunctionName binds the name of the function this scope belongs to,
+// so that it can call itself without capturing its own binding
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FunctionScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// Resolve looks name up in this and every enclosing scope. Locals of
+// enclosing functions are turned into free symbols of this scope.
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	obj, ok := s.store[name]
+	if ok || s.Outer == nil {
+		return obj, ok
+	}
+
+	obj, ok = s.Outer.Resolve(name)
+	if !ok {
+		return obj, ok
+	}
+	if obj.Scope == GlobalScope || obj.Scope == BuiltinScope {
+		return obj, ok
+	}
+	return s.defineFree(obj), true
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1, Scope: FreeScope}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+// NumDefinitions returns how many slots Define has handed out
+func (s *SymbolTable) NumDefinitions() int {
+	return s.numDefinitions
+}
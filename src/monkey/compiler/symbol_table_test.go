@@ -0,0 +1,146 @@
+package compiler
+
+import "testing"
+
+func TestDefine(t *testing.T) {
+	expected := map[string]Symbol{
+		"a": {Name: "a", Scope: GlobalScope, Index: 0},
+		"b": {Name: "b", Scope: GlobalScope, Index: 1},
+		"c": {Name: "c", Scope: LocalScope, Index: 0},
+		"d": {Name: "d", Scope: LocalScope, Index: 1},
+		"e": {Name: "e", Scope: LocalScope, Index: 0},
+		"f": {Name: "f", Scope: LocalScope, Index: 1},
+	}
+
+	global := NewSymbolTable()
+	firstLocal := NewEnclosedSymbolTable(global)
+	secondLocal := NewEnclosedSymbolTable(firstLocal)
+
+	tests := []struct {
+		table *SymbolTable
+		name  string
+	}{
+		{global, "a"},
+		{global, "b"},
+		{firstLocal, "c"},
+		{firstLocal, "d"},
+		{secondLocal, "e"},
+		{secondLocal, "f"},
+	}
+
+	for _, tt := range tests {
+		if sym := tt.table.Define(tt.name); sym != expected[tt.name] {
+			t.Errorf("expected %s=%+v, got=%+v", tt.name, expected[tt.name], sym)
+		}
+	}
+}
+
+func TestResolveNestedLocal(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	global.DefineBuiltin(0, "len")
+
+	firstLocal := NewEnclosedSymbolTable(global)
+	firstLocal.Define("c")
+	firstLocal.Define("d")
+
+	secondLocal := NewEnclosedSymbolTable(firstLocal)
+	secondLocal.Define("e")
+
+	tests := []struct {
+		table        *SymbolTable
+		expected     []Symbol
+		expectedFree []Symbol
+	}{
+		{
+			firstLocal,
+			[]Symbol{
+				{Name: "a", Scope: GlobalScope, Index: 0},
+				{Name: "len", Scope: BuiltinScope, Index: 0},
+				{Name: "c", Scope: LocalScope, Index: 0},
+				{Name: "d", Scope: LocalScope, Index: 1},
+			},
+			nil,
+		},
+		{
+			secondLocal,
+			[]Symbol{
+				{Name: "a", Scope: GlobalScope, Index: 0},
+				{Name: "len", Scope: BuiltinScope, Index: 0},
+				{Name: "d", Scope: FreeScope, Index: 0},
+				{Name: "c", Scope: FreeScope, Index: 1},
+				{Name: "e", Scope: LocalScope, Index: 0},
+				{Name: "d", Scope: FreeScope, Index: 0},
+			},
+			[]Symbol{
+				{Name: "d", Scope: LocalScope, Index: 1},
+				{Name: "c", Scope: LocalScope, Index: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, sym := range tt.expected {
+			result, ok := tt.table.Resolve(sym.Name)
+			if !ok {
+				t.Errorf("name %s not resolvable", sym.Name)
+				continue
+			}
+			if result != sym {
+				t.Errorf("expected %s to resolve to %+v, got=%+v", sym.Name, sym, result)
+			}
+		}
+
+		if len(tt.table.FreeSymbols) != len(tt.expectedFree) {
+			t.Errorf("wrong number of free symbols. want=%d, got=%d",
+				len(tt.expectedFree), len(tt.table.FreeSymbols))
+			continue
+		}
+		for i, sym := range tt.expectedFree {
+			if tt.table.FreeSymbols[i] != sym {
+				t.Errorf("wrong free symbol. want=%+v, got=%+v", sym, tt.table.FreeSymbols[i])
+			}
+		}
+	}
+}
+
+func TestResolveUnresolvable(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	local := NewEnclosedSymbolTable(global)
+	local.Define("b")
+
+	for _, name := range []string{"x", "y"} {
+		if _, ok := local.Resolve(name); ok {
+			t.Errorf("name %s resolved, but was expected not to", name)
+		}
+	}
+	if len(local.FreeSymbols) != 0 {
+		t.Errorf("unresolvable names were made free: %+v", local.FreeSymbols)
+	}
+}
+
+func TestShadowing(t *testing.T) {
+	global := NewSymbolTable()
+	global.DefineBuiltin(0, "len")
+	global.Define("a")
+
+	local := NewEnclosedSymbolTable(global)
+	local.DefineFunctionName("a")
+	local.Define("len")
+
+	tests := []Symbol{
+		{Name: "a", Scope: FunctionScope, Index: 0},
+		{Name: "len", Scope: LocalScope, Index: 0},
+	}
+
+	for _, sym := range tests {
+		if result, ok := local.Resolve(sym.Name); !ok || result != sym {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", sym.Name, sym, result)
+		}
+	}
+
+	if result, _ := global.Resolve("len"); result.Scope != BuiltinScope {
+		t.Errorf("local definition leaked into the global scope: %+v", result)
+	}
+}
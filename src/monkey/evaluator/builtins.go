@@ -0,0 +1,9 @@
+package evaluator
+
+import "monkey/object"
+
+// RegisterBuiltin makes fn callable from every program as name, see
+// object.RegisterBuiltin. Bindings made with let shadow builtins.
+func RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	object.RegisterBuiltin(name, fn)
+}
@@ -0,0 +1,142 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("größe")`, 5},
+		{`len([1, 2, 3])`, 3},
+		{`len({"a": 1})`, 1},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments: want=1, got=2"},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`rest([1, 2, 3])`, []int64{2, 3}},
+		{`rest([1])`, []int64{}},
+		{`rest([])`, nil},
+		{`push([], 1)`, []int64{1}},
+		{`let a = [1]; let b = push(a, 2); len(a)`, 1},
+		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`push([1])`, "wrong number of arguments: want=2, got=1"},
+		{`puts()`, nil},
+		{`type(1)`, "=INTEGER"},
+		{`type(9223372036854775808)`, "=BIGINT"},
+		{`type("a")`, "=STRING"},
+		{`type([])`, "=ARRAY"},
+		{`type(fn() {})`, "=FUNCTION"},
+		{`type(len)`, "=BUILTIN"},
+		{`str(12)`, "=12"},
+		{`str("a")`, "=a"},
+		{`str([1, true])`, "=[1, true]"},
+		{`str(1.5)`, "=1.5"},
+		{`int("42")`, 42},
+		{`int(" -0x_ff ")`, -255},
+		{`int("010")`, 10},
+		{`int("+0o17")`, 15},
+		{`int("0b1_01")`, 5},
+		{`int("-9223372036854775808")`, -9223372036854775808},
+		{`int("--1")`, `could not convert "--1" to INTEGER`},
+		{`int("1__0")`, `could not convert "1__0" to INTEGER`},
+		{`int("09x")`, `could not convert "09x" to INTEGER`},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(true)`, 1},
+		{`int(7)`, 7},
+		{`int("abc")`, `could not convert "abc" to INTEGER`},
+		{`int([])`, "argument to `int` not supported, got ARRAY"},
+		{`let len = fn(x) { 42 }; len([])`, 42},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case []int64:
+			arr, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("%s: object is not Array. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if len(arr.Elements) != len(expected) {
+				t.Errorf("%s: wrong number of elements. want=%d, got=%d",
+					tt.input, len(expected), len(arr.Elements))
+				continue
+			}
+			for i, e := range expected {
+				testIntegerObject(t, arr.Elements[i], e)
+			}
+		case string:
+			// strings starting with = are expected String results
+			if expected[0] == '=' {
+				str, ok := evaluated.(*object.String)
+				if !ok {
+					t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+					continue
+				}
+				if str.Value != expected[1:] {
+					t.Errorf("%s: String has wrong value. want=%q, got=%q", tt.input, expected[1:], str.Value)
+				}
+				continue
+			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestBuiltinIntBigValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`int("123456789012345678901234567890")`, "123456789012345678901234567890"},
+		{`int(1e20)`, "100000000000000000000"},
+		{`int("-0x1_0000_0000_0000_0000")`, "-18446744073709551616"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("%s: object is not BigInt. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if result.Value.String() != tt.expected {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tt.input, result.Value, tt.expected)
+		}
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	t.Cleanup(object.SnapshotBuiltins())
+	RegisterBuiltin("double", func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments: want=1, got=%d", len(args))
+		}
+		n, ok := args[0].(*object.Integer)
+		if !ok {
+			return newError("argument to `double` must be INTEGER, got %s", args[0].Type())
+		}
+		return &object.Integer{Value: n.Value * 2}
+	})
+	RegisterBuiltin("nothing", func(args ...object.Object) object.Object { return nil })
+
+	testIntegerObject(t, testEval(t, `double(21)`), 42)
+	testIntegerObject(t, testEval(t, `let f = fn(g) { g(5) }; f(double)`), 10)
+	testErrorObject(t, testEval(t, `double("a")`), "argument to `double` must be INTEGER, got STRING")
+	testNullObject(t, testEval(t, `nothing()`))
+}
@@ -0,0 +1,728 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"monkey/ast"
+	"monkey/object"
+)
+
+var (
+	// NULL is the single null value
+	NULL = &object.Null{}
+	// TRUE is the single true value
+	TRUE = &object.Boolean{Value: true}
+	// FALSE is the single false value
+	FALSE = &object.Boolean{Value: false}
+)
+
+// Eval evaluates node within env and returns the resulting object. An
+// Error is positioned at the innermost node that failed.
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := eval(node, env)
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.LetStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInt{Value: node.Big}
+		}
+		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalInfixExpression(node.Operator, left, right)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.FunctionLiteral:
+		return &object.Function{
+			Name:       node.Name,
+			Parameters: node.Parameters,
+			Body:       node.Body,
+			Env:        env,
+		}
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		result := applyFunction(function, args)
+		if err, ok := result.(*object.Error); ok {
+			if fn, ok := function.(*object.Function); ok && err.Pos.IsValid() {
+				err.Stack = append(err.Stack, object.Frame{Function: functionName(fn), Call: node.Pos()})
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+// evalBlockStatement keeps return values wrapped so that they unwind
+// through every enclosing block. An empty block, or one ending in a let,
+// evaluates to NULL.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object = NULL
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result == nil {
+			result = NULL
+			continue
+		}
+		rt := result.Type()
+		if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			return result
+		}
+	}
+	return result
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return Eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return Eval(ie.Alternative, env)
+	}
+	return NULL
+}
+
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+	switch function := fn.(type) {
+	case *object.Function:
+		if len(args) != len(function.Parameters) {
+			return newError("wrong number of arguments: want=%d, got=%d",
+				len(function.Parameters), len(args))
+		}
+		extendedEnv := extendFunctionEnv(function, args)
+		evaluated := Eval(function.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		if result := function.Fn(args...); result != nil {
+			return result
+		}
+		return NULL
+	default:
+		return newError("not a function: %s", fn.Type())
+	}
+}
+
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "fn"
+	}
+	return fn.Name
+}
+
+// extendFunctionEnv binds the arguments in a scope enclosed by the
+// environment the function was defined in, not the one it is called from
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(fn.Env)
+	for i, param := range fn.Parameters {
+		env.Set(param.Value, args[i])
+	}
+	return env
+}
+
+// unwrapReturnValue stops a return from unwinding past the function call
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	if obj == nil {
+		return NULL
+	}
+	return obj
+}
+
+// evalLogicalExpression evaluates && and ||, only evaluating the right
+// operand when the left one does not already decide the result
+func evalLogicalExpression(ie *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(ie.Left, env)
+	if isError(left) {
+		return left
+	}
+	if isTruthy(left) == (ie.Operator == "||") {
+		return nativeBoolToBooleanObject(isTruthy(left))
+	}
+
+	right := Eval(ie.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+// evalExpressions evaluates exps from left to right, stopping at the
+// first error which is then returned on its own
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && isInteger(index):
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		// a BigInt is outside the int64 range, so never a valid index
+		return newError("index out of range: %s (array length %d)", index.Inspect(), len(elements))
+	}
+	idx := integer.Value
+
+	if idx < 0 || idx >= int64(len(elements)) {
+		return newError("index out of range: %d (array length %d)", idx, len(elements))
+	}
+	return elements[idx]
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+	return &object.Hash{Pairs: pairs}
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return NULL
+	}
+	return pair.Value
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if builtin, ok := object.LookupBuiltin(node.Value); ok {
+		return builtin
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalBitwiseNotOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBitwiseNotOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInt:
+		return object.NormalizeBigInt(new(big.Int).Not(right.Value))
+	default:
+		return newError("unknown operator: ~%s", right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NormalizeBigInt(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return object.NormalizeBigInt(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// an integer mixed with a float is promoted to float
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	// results that overflow int64 are redone with arbitrary precision
+	switch operator {
+	case "+":
+		result := leftVal + rightVal
+		if (leftVal^result)&(rightVal^result) < 0 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "-":
+		result := leftVal - rightVal
+		if (leftVal^rightVal)&(leftVal^result) < 0 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "*":
+		if leftVal == 0 || rightVal == 0 {
+			return &object.Integer{Value: 0}
+		}
+		result := leftVal * rightVal
+		if result/rightVal != leftVal || (leftVal == -1 && rightVal == math.MinInt64) ||
+			(rightVal == -1 && leftVal == math.MinInt64) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: result}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %d %% %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		if rightVal < 0 {
+			return newError("negative exponent: %d ** %d", leftVal, rightVal)
+		}
+		if result, ok := integerPower(leftVal, rightVal); ok {
+			return &object.Integer{Value: result}
+		}
+		return evalBigIntInfixExpression(operator, left, right)
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<":
+		if rightVal < 0 {
+			return newError("negative shift count: %d << %d", leftVal, rightVal)
+		}
+		if rightVal >= 63 || (leftVal<<uint(rightVal))>>uint(rightVal) != leftVal {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: leftVal << uint(rightVal)}
+	case ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d >> %d", leftVal, rightVal)
+		}
+		if rightVal >= 63 {
+			rightVal = 63
+		}
+		return &object.Integer{Value: leftVal >> uint(rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalBigIntInfixExpression evaluates integer operators with arbitrary
+// precision, handing back an Integer whenever the result fits
+func evalBigIntInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := bigIntValue(left)
+	rightVal := bigIntValue(right)
+
+	switch operator {
+	case "+":
+		return object.NormalizeBigInt(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NormalizeBigInt(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NormalizeBigInt(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero: %s / %s", leftVal, rightVal)
+		}
+		// Quo truncates towards zero like int64 division
+		return object.NormalizeBigInt(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero: %s %% %s", leftVal, rightVal)
+		}
+		// Rem takes the sign of the dividend like int64 modulo
+		return object.NormalizeBigInt(new(big.Int).Rem(leftVal, rightVal))
+	case "**":
+		if rightVal.Sign() < 0 {
+			return newError("negative exponent: %s ** %s", leftVal, rightVal)
+		}
+		// the result has at least (bits of |left| - 1) * right bits
+		if leftVal.CmpAbs(big.NewInt(1)) > 0 &&
+			(!rightVal.IsUint64() || rightVal.Uint64() >= maxIntegerBits ||
+				uint64(leftVal.BitLen()-1)*rightVal.Uint64() >= maxIntegerBits) {
+			return newError("exponent too large: %s ** %s", leftVal, rightVal)
+		}
+		return object.NormalizeBigInt(new(big.Int).Exp(leftVal, rightVal, nil))
+	case "&":
+		return object.NormalizeBigInt(new(big.Int).And(leftVal, rightVal))
+	case "|":
+		return object.NormalizeBigInt(new(big.Int).Or(leftVal, rightVal))
+	case "^":
+		return object.NormalizeBigInt(new(big.Int).Xor(leftVal, rightVal))
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s %s %s", leftVal, operator, rightVal)
+		}
+		if operator == ">>" && (!rightVal.IsUint64() || rightVal.Uint64() >= uint64(leftVal.BitLen())) {
+			// every bit is shifted out, like the clamped INTEGER shift
+			if leftVal.Sign() < 0 {
+				return &object.Integer{Value: -1}
+			}
+			return &object.Integer{Value: 0}
+		}
+		if !rightVal.IsUint64() || rightVal.Uint64() > maxIntegerBits ||
+			rightVal.Uint64()+uint64(leftVal.BitLen()) > maxIntegerBits {
+			return newError("shift count too large: %s %s %s", leftVal, operator, rightVal)
+		}
+		if operator == "<<" {
+			return object.NormalizeBigInt(new(big.Int).Lsh(leftVal, uint(rightVal.Uint64())))
+		}
+		return object.NormalizeBigInt(new(big.Int).Rsh(leftVal, uint(rightVal.Uint64())))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// maxIntegerBits bounds the size of the results of << and **, so that a
+// typo such as 2 ** 2 ** 40 fails instead of exhausting memory
+const maxIntegerBits = 1 << 20
+
+// integerPower computes base ** exp by squaring, reporting false when an
+// intermediate result overflows int64
+func integerPower(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			r := result * base
+			if base != 0 && (r/base != result || (base == -1 && result == math.MinInt64)) {
+				return 0, false
+			}
+			result = r
+		}
+		exp >>= 1
+		if exp > 0 {
+			sq := base * base
+			if base != 0 && (sq/base != base || base == math.MinInt64) {
+				return 0, false
+			}
+			base = sq
+		}
+	}
+	return result, true
+}
+
+func isInteger(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.BIGINT_OBJ
+}
+
+// bigIntValue converts an Integer or BigInt to *big.Int
+func bigIntValue(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInt:
+		return obj.Value
+	}
+	return new(big.Int)
+}
+
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := floatValue(left)
+	rightVal := floatValue(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return isInteger(obj) || obj.Type() == object.FLOAT_OBJ
+}
+
+// floatValue converts an Integer, BigInt or Float to float64
+func floatValue(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	}
+	return 0
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
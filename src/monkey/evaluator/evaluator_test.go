@@ -0,0 +1,917 @@
+package evaluator
+
+import (
+	"math/big"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"0xFF - 0b1111_1111 + 0o10", 8},
+		{"1_000 * 1_000", 1000000},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"1 << 4", 16},
+		{"-16 >> 2", -4},
+		{"1 >> 64", 0},
+		{"-1 >> 100", -1},
+		{"1 >> 18446744073709551616", 0},
+		{"-5 >> 18446744073709551616", -1},
+		{"(1 << 100) >> 99", 2},
+		{"(1 << 100) >> 200", 0},
+		{"-(1 << 100) >> 18446744073709551616", -1},
+		{"1 + 2 << 3", 24},
+		{"0xFF & 0x0F | 0x30", 63},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.25", -2.25},
+		{"1.5 + 1.5", 3.0},
+		{"0.5 * 4.0", 2.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"3 * 1.5", 4.5},
+		{"10 - 2.5", 7.5},
+		{"1 / 4.0", 0.25},
+		{"7.5 / 2", 3.75},
+		{"1e3 + 1", 1001.0},
+		{"2 * (1.5 + 1)", 5.0},
+		{"7.5 % 2", 1.5},
+		{"2 ** 0.5 * 2 ** 0.5", 2.0000000000000004},
+		{"2.0 ** 3", 8.0},
+		{"4 ** -1.0", 0.25},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestMixedNumberComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"1 < 1.5", true},
+		{"2.5 > 3", false},
+		{"1 <= 1.0", true},
+		{"2.5 >= 3", false},
+		{"0.1 + 0.2 == 0.3", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIntegerOverflowPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-9223372036854775807 - 1 - 1", "-9223372036854775809"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"2 ** 64", "18446744073709551616"},
+		{"(-2) ** 63 * 2", "-18446744073709551616"},
+		{"1 << 64", "18446744073709551616"},
+		{"3 << 62", "13835058055282163712"},
+		{"18446744073709551616 | 1", "18446744073709551617"},
+		{"~18446744073709551616", "-18446744073709551617"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"123456789012345678901234567890 * 10", "1234567890123456789012345678900"},
+		{"-123456789012345678901234567890", "-123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("%s: object is not BigInt. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if result.Value.String() != tt.expected {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tt.input, result.Value, tt.expected)
+		}
+	}
+}
+
+func TestBigIntDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"9223372036854775808 - 1", 9223372036854775807},
+		{"-9223372036854775808", -9223372036854775808},
+		{"18446744073709551616 / 4294967296", 4294967296},
+		{"let big = 9223372036854775807 + 10; big - 10", 9223372036854775807},
+		{"100000000000000000000 - 99999999999999999999", 1},
+		{"(-2) ** 63", -9223372036854775808},
+		{"18446744073709551617 % 4294967296", 1},
+		{"18446744073709551616 >> 60", 16},
+		{"18446744073709551617 & 0xFF", 1},
+		{"18446744073709551616 ^ 18446744073709551617", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBigIntComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"9223372036854775808 > 9223372036854775807", true},
+		{"9223372036854775808 < 1", false},
+		{"9223372036854775807 + 1 == 9223372036854775808", true},
+		{"9223372036854775808 != 9223372036854775808", false},
+		{"9223372036854775808 == 9223372036854775808.0", true},
+		{"9223372036854775808 > 0.5", true},
+		{"9223372036854775808 >= 9223372036854775808", true},
+		{"9223372036854775808 <= 9223372036854775807", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"1 < 2 == true", true},
+		{"1 > 2 == true", false},
+		{"(1 < 2) == true", true},
+		{"(1 > 2) == false", true},
+		{"1 <= 1", true},
+		{"2 <= 1", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"5 & 1 == 1", true},
+		{"6 % 4 == 2 && 1 << 2 > 3", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"1 && \"\"", true},
+		{"false || 0", true},
+		{"if (false) { 1 } || true", true},
+		{"false && true || true", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLogicalOperatorsShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		// the right operand would be an error if it were evaluated
+		{"false && undefined", false},
+		{"true || 1 + true", true},
+		{"let x = 0; x != 0 && 10 / x > 1", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+
+	evaluated := testEval(t, "true && undefined")
+	testErrorObject(t, evaluated, "identifier not found: undefined")
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(t, input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `"Hello" + " " + "World!\n"`
+
+	evaluated := testEval(t, input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!\n" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" + "b" == "ab"`, true},
+		{`"caf\u{e9}" == "café"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(t, input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+		{"[1, 2, 3][3]", "index out of range: 3 (array length 3)"},
+		{"[1, 2, 3][-1]", "index out of range: -1 (array length 3)"},
+		{"[][0]", "index out of range: 0 (array length 0)"},
+		{"[1][1 << 70]", "index out of range: 1180591620717411303424 (array length 1)"},
+		{"[1][-(1 << 70)]", "index out of range: -1180591620717411303424 (array length 1)"},
+		{`[1, 2, 3]["a"]`, "index operator not supported: ARRAY[STRING]"},
+		{"1[0]", "index operator not supported: INTEGER[INTEGER]"},
+		{"[1, foo, 3]", "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(t, input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{9223372036854775808: 5}[9223372036854775807 + 1]`, 5},
+		{`{"foo": 5}[[1]]`, "unusable as hash key: ARRAY"},
+		{`{[1]: 5}`, "unusable as hash key: ARRAY"},
+		{`{"a": foo}`, "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 < 1) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (2 < 1) { 20 }", nil},
+		{"if (true) {}", nil},
+		{"if (true) { let a = 1; }", nil},
+		{"if (true) {} == 1", false},
+		{"!(if (true) {})", true},
+		{"let x = if (true) {}; x + 1", "type mismatch: NULL + INTEGER"},
+		{"len([if (false) {1} else {}])", 1},
+		{"len({1: if (true) {}})", 1},
+		{"len(if (true) {})", "argument to `len` not supported, got NULL"},
+		{"puts(if (true) {})", nil},
+		{"fn() {}()", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"return;", nil},
+		{"9; return", nil},
+		{
+			`if (10 > 1) {
+				if (10 > 1) {
+					return 10;
+				}
+				return 1;
+			}`,
+			10,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
+		{`"Hello" + 1`, "type mismatch: STRING + INTEGER"},
+		{
+			`if (10 > 1) {
+				if (10 > 1) {
+					return true + false;
+				}
+				return 1;
+			}`,
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{"10 / 0", "division by zero: 10 / 0"},
+		{"1.5 / 0", "division by zero: 1.5 / 0"},
+		{"1 / 0.0", "division by zero: 1 / 0.0"},
+		{"9223372036854775808 / 0", "division by zero: 9223372036854775808 / 0"},
+		{"9223372036854775808 + true", "type mismatch: BIGINT + BOOLEAN"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{`"a" + 1.5`, "type mismatch: STRING + FLOAT"},
+		{"{1.5: 1}", "unusable as hash key: FLOAT"},
+		{"10 % 0", "modulo by zero: 10 % 0"},
+		{"1.5 % 0.0", "modulo by zero: 1.5 % 0.0"},
+		{"18446744073709551616 % 0", "modulo by zero: 18446744073709551616 % 0"},
+		{"2 ** -1", "negative exponent: 2 ** -1"},
+		{"1 << -1", "negative shift count: 1 << -1"},
+		{"1 >> -1", "negative shift count: 1 >> -1"},
+		{"1 << 18446744073709551616", "shift count too large: 1 << 18446744073709551616"},
+		{"2 ** 1048577", "exponent too large: 2 ** 1048577"},
+		{"(2 ** 1000000) ** 1000000", "exponent too large: " + bigPowerOfTwo(1000000) + " ** 1000000"},
+		{"(2 ** 1000000) << 100000", "shift count too large: " + bigPowerOfTwo(1000000) + " << 100000"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+		{"~1.5", "unknown operator: ~FLOAT"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"5(1)", "not a function: INTEGER"},
+		{"let f = fn(x, y) { x }; f(1)", "wrong number of arguments: want=2, got=1"},
+		{"let f = fn(x) { x + true }; f(1); 2", "type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(x) { x }; f(y)", "identifier not found: y"},
+		{"let f = fn() { x }; let g = fn() { let x = 1; f() }; g()", "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let größe = 5; let 変数2 = größe * 2; 変数2;", 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(t, input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let f = fn() { return 1; 2 }; f() + 10", 11},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+
+	testNullObject(t, testEval(t, "fn() {}()"))
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{
+			`let makeAdder = fn(x) { fn(y) { x + y } };
+			let addTwo = makeAdder(2);
+			addTwo(3);`,
+			5,
+		},
+		{
+			// currying
+			`let add = fn(a) { fn(b) { fn(c) { a + b + c } } };
+			add(1)(2)(3);`,
+			6,
+		},
+		{
+			// a counter hands out its successor instead of mutating itself
+			`let counter = fn(n) { {"value": n, "next": fn() { counter(n + 1) }} };
+			let c = counter(0);
+			c["next"]()["next"]()["value"];`,
+			2,
+		},
+		{
+			// each call captures its own environment
+			`let counter = fn(n) { {"value": n, "next": fn() { counter(n + 1) }} };
+			let a = counter(0)["next"]();
+			let b = counter(10);
+			a["value"] + b["value"];`,
+			11,
+		},
+		{
+			// scoping is lexical, not dynamic
+			`let x = 1;
+			let f = fn() { x };
+			let g = fn(x) { f() };
+			g(100);`,
+			1,
+		},
+		{
+			// parameters shadow outer bindings without changing them
+			`let x = 1;
+			let f = fn(x) { let x = x * 10; x };
+			f(5) + x;`,
+			51,
+		},
+		{
+			// the closure sees bindings made after it was created
+			`let f = fn() { later };
+			let later = 7;
+			f();`,
+			7,
+		},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestRecursiveFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{
+			`let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) };
+			fib(15);`,
+			610,
+		},
+		{
+			`let fact = fn(n) { if (n == 0) { 1 } else { n * fact(n - 1) } };
+			fact(10);`,
+			3628800,
+		},
+		{
+			// a counter built from recursion instead of mutation
+			`let countDown = fn(n, acc) { if (n == 0) { acc } else { countDown(n - 1, acc + 1) } };
+			countDown(100, 0);`,
+			100,
+		},
+		{
+			`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+			let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+			if (isEven(10) && isOdd(7)) { 1 } else { 0 }`,
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true;", "1:3"},
+		{"let x = 1;\nlet y = -true;", "2:9"},
+		{"let x = 1;\n  foobar", "2:3"},
+		{"[1, 2][5]", "1:7"},
+		{"if (1) {\n  1 / 0\n}", "2:5"},
+		{"len(1)", "1:1"},
+		{"1 + len([], [])", "1:5"},
+		{"let f = fn(x) { x };\nf()", "2:1"},
+		{"true && x", "1:9"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Pos.String() != tt.expected {
+			t.Errorf("%q: wrong error position. want=%s, got=%s", tt.input, tt.expected, errObj.Pos)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let add = fn(a, b) {
+  a + b
+};
+let twice = fn(x) { add(x, true) };
+let apply = fn(f) { f(1) };
+apply(twice)`
+
+	evaluated := testEval(t, input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		function string
+		call     string
+	}{
+		{"add", "4:21"},
+		{"twice", "5:21"},
+		{"apply", "6:1"},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong number of frames. want=%d, got=%d (%+v)",
+			len(expected), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range expected {
+		got := errObj.Stack[i]
+		if got.Function != frame.function || got.Call.String() != frame.call {
+			t.Errorf("frame[%d] wrong. want=%s at %s, got=%s at %s",
+				i, frame.function, frame.call, got.Function, got.Call)
+		}
+	}
+
+	expectedTrace := `ERROR: type mismatch: INTEGER + BOOLEAN
+	2:5 in add()
+	4:21 in twice()
+	5:21 in apply()
+	6:1 in main`
+	if errObj.Trace() != expectedTrace {
+		t.Errorf("wrong trace. want=\n%s\ngot=\n%s", expectedTrace, errObj.Trace())
+	}
+}
+
+func TestErrorStackTraceAnonymous(t *testing.T) {
+	evaluated := testEval(t, "let f = fn(g) { g() }; f(fn() { 1 / 0 })")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expectedTrace := `ERROR: division by zero: 1 / 0
+	1:35 in fn()
+	1:17 in f()
+	1:24 in main`
+	if errObj.Trace() != expectedTrace {
+		t.Errorf("wrong trace. want=\n%s\ngot=\n%s", expectedTrace, errObj.Trace())
+	}
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	env := object.NewEnvironment()
+	return Eval(program, env)
+}
+
+// bigPowerOfTwo returns 2 ** n in decimal
+func bigPowerOfTwo(n uint) string {
+	return new(big.Int).Lsh(big.NewInt(1), n).String()
+}
+
+func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
+	errObj, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("object is not Error. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+		return false
+	}
+	return true
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
@@ -1,22 +1,87 @@
 package lexer
 
-import "monkey/token"
+import (
+	"bufio"
+	"io"
+	"monkey/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
+// Lexer turns UTF-8 encoded source into tokens. It reads its input
+// incrementally, holding only the current rune and one rune of lookahead.
 type Lexer struct {
-	input    string
-	position int
-	ch       byte
+	reader       *bufio.Reader
+	err          error // first read error other than io.EOF
+	filename     string
+	comments     bool // emit COMMENT tokens instead of skipping comments
+	position     int  // byte offset of ch
+	readPosition int  // byte offset of the rune after ch
+	ch           rune
+	chBytes      []byte // encoding of ch as found in the input
+	line         int    // line of ch
+	column       int    // column of ch, counted in runes
+
+	// literal collects the bytes of the token being read, see startLiteral
+	literal   []byte
+	recording bool
+}
+
+// Option configures a Lexer
+type Option func(*Lexer)
+
+// WithFilename records name as the Filename of every token position
+func WithFilename(name string) Option {
+	return func(l *Lexer) {
+		l.filename = name
+	}
+}
+
+// WithComments makes NextToken return comments as COMMENT tokens, so that
+// tools such as formatters can preserve them
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.comments = true
+	}
 }
 
-func New(input string) *Lexer {
-	l := &Lexer{input: input}
+// New creates a Lexer over a program held in memory
+func New(input string, opts ...Option) *Lexer {
+	return NewReader(strings.NewReader(input), opts...)
+}
+
+// NewReader creates a Lexer that reads the program from r as tokens are
+// requested, so that large scripts and piped input need not be loaded
+// upfront. It produces the same tokens as New given the same program.
+func NewReader(r io.Reader, opts ...Option) *Lexer {
+	l := &Lexer{reader: bufio.NewReader(r), line: 1}
+	for _, opt := range opts {
+		opt(l)
+	}
 	l.readChar()
 	return l
 }
 
+// Err returns the first error, other than io.EOF, met while reading the
+// input. The lexer treats such an error as the end of input.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
-	l.skipWhiteSpaces()
+	for l.skipWhiteSpaces(); l.isCommentStart(); l.skipWhiteSpaces() {
+		pos := l.currentPosition()
+		literal, ok := l.readComment()
+		if !ok {
+			return token.Token{Type: token.ILLEGAL, Literal: literal, Pos: pos}
+		}
+		if l.comments {
+			return token.Token{Type: token.COMMENT, Literal: literal, Pos: pos}
+		}
+	}
+	pos := l.currentPosition()
 
 	switch l.ch {
 	case '=':
@@ -45,20 +110,84 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '-':
 		tok = newToken(token.MINUS, l.ch)
 	case '/':
 		tok = newToken(token.SLASH, l.ch)
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			tok.Literal = "**"
+			tok.Type = token.POWER
+			l.readChar()
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = "<="
+			tok.Type = token.LT_EQ
+			l.readChar()
+		} else if l.peekChar() == '<' {
+			tok.Literal = "<<"
+			tok.Type = token.LSHIFT
+			l.readChar()
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = ">="
+			tok.Type = token.GT_EQ
+			l.readChar()
+		} else if l.peekChar() == '>' {
+			tok.Literal = ">>"
+			tok.Type = token.RSHIFT
+			l.readChar()
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			tok.Literal = "&&"
+			tok.Type = token.AND
+			l.readChar()
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			tok.Literal = "||"
+			tok.Type = token.OR
+			l.readChar()
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '"':
+		l.startLiteral()
+		if str, ok := l.readString(&tok); ok {
+			l.endLiteral()
+			tok.Type = token.STRING
+			tok.Literal = str
+		} else {
+			tok.Type = token.ILLEGAL
+			tok.Literal = l.endLiteral() + string(l.chBytes)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -66,32 +195,189 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdentifier(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Pos = pos
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			// the raw bytes, so that invalid UTF-8 is reported as found
+			tok.Type = token.ILLEGAL
+			tok.Literal = string(l.chBytes)
 		}
 	}
 	l.readChar()
+	tok.Pos = pos
 	return tok
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position - 1
-	for isDigit(l.ch) {
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+// readNumber reads an integer or a floating point literal such as 3.14,
+// 1e-9 or 6.02E23. Integers may carry a 0x, 0o or 0b base prefix and any
+// number may use underscores as digit separators; the literal is read
+// greedily and validated by the parser.
+func (l *Lexer) readNumber() (token.TokenType, string) {
+	l.startLiteral()
+	if l.ch == '0' && isBasePrefix(l.peekChar()) {
+		l.readChar()
+		l.readChar()
+		for isLetter(l.ch) || isDigit(l.ch) {
+			l.readChar()
+		}
+		return token.INT, l.endLiteral()
+	}
+
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	if (l.ch == 'e' || l.ch == 'E') && (isDigit(l.peekChar()) || l.peekChar() == '+' || l.peekChar() == '-') {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits()
+	}
+	return tokenType, l.endLiteral()
+}
+
+// readDigits reads decimal digits and _ separators
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// readString reads a double quoted literal, decoding its escape sequences.
+// It reports false for unterminated literals and invalid escapes, in which
+// case the lexer is left on the closing quote or at the end of input. The
+// first invalid escape is recorded in tok.
+func (l *Lexer) readString(tok *token.Token) (string, bool) {
+	var out strings.Builder
+	valid := true
+	for {
 		l.readChar()
+		switch l.ch {
+		case '"':
+			return out.String(), valid
+		case 0:
+			return out.String(), false
+		case '\\':
+			start, pos := len(l.literal), l.currentPosition()
+			l.readChar()
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case '"', '\\':
+				out.WriteRune(l.ch)
+			case 'u':
+				r, ok := l.readUnicodeEscape()
+				if !ok {
+					l.badEscape(tok, start, pos)
+					valid = false
+				}
+				out.WriteRune(r)
+			case 0:
+				return out.String(), false
+			default:
+				l.badEscape(tok, start, pos)
+				valid = false
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// badEscape records the escape sequence read since the literal offset start
+// as the first invalid one of tok
+func (l *Lexer) badEscape(tok *token.Token, start int, pos token.Position) {
+	if tok.BadEscape == "" {
+		tok.BadEscape = string(l.literal[start:]) + string(l.chBytes)
+		tok.BadEscapePos = pos
 	}
-	return l.input[position : l.position-1]
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.position >= len(l.input) {
-		return 0
+// readUnicodeEscape reads the {XXXX} part of a \u{XXXX} escape, leaving
+// the lexer on the closing brace
+func (l *Lexer) readUnicodeEscape() (rune, bool) {
+	if l.peekChar() != '{' {
+		return 0, false
+	}
+	l.readChar()
+
+	var r rune
+	digits := 0
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+		r = r<<4 | hexValue(l.ch)
+		digits++
+		if digits > 6 {
+			return 0, false
+		}
+	}
+	if digits == 0 || l.peekChar() != '}' {
+		return 0, false
+	}
+	l.readChar()
+
+	if r > 0x10FFFF || (r >= 0xD800 && r <= 0xDFFF) {
+		return 0, false
 	}
-	return l.input[l.position]
+	return r, true
+}
+
+func (l *Lexer) isCommentStart() bool {
+	return l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*')
+}
+
+// readComment reads a // comment up to the end of the line, or a /* */
+// comment which may nest. It reports false for an unterminated /* comment.
+func (l *Lexer) readComment() (string, bool) {
+	l.startLiteral()
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		return l.endLiteral(), true
+	}
+
+	l.readChar()
+	l.readChar()
+	for depth := 1; depth > 0; {
+		switch {
+		case l.ch == 0:
+			return l.endLiteral(), false
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+		l.readChar()
+	}
+	return l.endLiteral(), true
+}
+
+func (l *Lexer) peekChar() rune {
+	r, _ := l.decodeNext()
+	return r
 }
 
 func (l *Lexer) skipWhiteSpaces() {
@@ -100,31 +386,110 @@ func (l *Lexer) skipWhiteSpaces() {
 	}
 }
 
+// readIdentifier reads a letter followed by letters and digits
 func (l *Lexer) readIdentifier() string {
-	position := l.position - 1
-	for isLetter(l.ch) {
+	l.startLiteral()
+	for isLetter(l.ch) || isIdentifierDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position : l.position-1]
+	return l.endLiteral()
 }
 
 func (l *Lexer) readChar() {
-	if l.position >= len(l.input) {
-		l.ch = 0
+	if l.recording {
+		l.literal = append(l.literal, l.chBytes...)
+	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 1
 	} else {
-		l.ch = l.input[l.position]
+		l.column++
+	}
+
+	l.position = l.readPosition
+	r, width := l.decodeNext()
+	if width == 0 {
+		l.ch = 0
+		l.chBytes = l.chBytes[:0]
+		return
 	}
-	l.position += 1
+	buf, _ := l.reader.Peek(width)
+	l.chBytes = append(l.chBytes[:0], buf...)
+	l.reader.Discard(width)
+	l.ch = r
+	l.readPosition += width
+}
+
+// decodeNext decodes the rune following ch without consuming it. It
+// returns a width of 0 at the end of input. Invalid UTF-8 decodes as
+// utf8.RuneError with a width of 1.
+func (l *Lexer) decodeNext() (rune, int) {
+	buf, err := l.reader.Peek(1)
+	if len(buf) == 0 {
+		if err != io.EOF && l.err == nil {
+			l.err = err
+		}
+		return 0, 0
+	}
+	if buf[0] < utf8.RuneSelf {
+		return rune(buf[0]), 1
+	}
+	// only ask for as many bytes as a rune may need, since waiting on
+	// more would block interactive input
+	buf, _ = l.reader.Peek(utf8.UTFMax)
+	return utf8.DecodeRune(buf)
+}
+
+// startLiteral starts collecting the bytes of a token, beginning with ch
+func (l *Lexer) startLiteral() {
+	l.literal = l.literal[:0]
+	l.recording = true
+}
+
+// endLiteral returns the bytes read since startLiteral, excluding ch
+func (l *Lexer) endLiteral() string {
+	l.recording = false
+	return string(l.literal)
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-func isDigit(ch byte) bool {
+// isDigit only accepts ASCII digits, which are the only ones allowed in
+// number literals
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isIdentifierDigit(ch rune) bool {
+	return isDigit(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) rune {
+	switch {
+	case isDigit(ch):
+		return ch - '0'
+	case 'a' <= ch && ch <= 'f':
+		return ch - 'a' + 10
+	default:
+		return ch - 'A' + 10
+	}
+}
+
+func isBasePrefix(ch rune) bool {
+	switch ch {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
 }
@@ -1,8 +1,12 @@
 package lexer
 
 import (
+	"errors"
+	"io"
 	"monkey/token"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNextTokenEqual(t *testing.T) {
@@ -98,7 +102,7 @@ func TestNextTokenTrueFalseIfElse(t *testing.T) {
 }
 
 func TestNextTokenMinusAsterisk(t *testing.T) {
-	input := `!-/*5;
+	input := `!-/ *5;
 	5 < 10 > 5;`
 	tests := []struct {
 		expectedType    token.TokenType
@@ -212,9 +216,9 @@ func TestReadNumber(t *testing.T) {
 		t.Fatalf("expected a identifier, got %q", idf)
 	}
 	l.readChar()
-	idf = l.readNumber()
-	if idf != "50" {
-		t.Fatalf("expected a identifier, got %q", idf)
+	tokenType, idf := l.readNumber()
+	if tokenType != token.INT || idf != "50" {
+		t.Fatalf("expected a number, got %s %q", tokenType, idf)
 	}
 
 }
@@ -420,7 +424,7 @@ func TestReadChar(t *testing.T) {
 	input = `abcd`
 	l = New(input)
 	for i := 0; i < len(input); i++ {
-		c := input[i]
+		c := rune(input[i])
 		if l.ch != c {
 			t.Fatalf("test failed, expected=%q, got=%q",
 				c, l.ch)
@@ -429,3 +433,501 @@ func TestReadChar(t *testing.T) {
 	}
 
 }
+
+func TestNextTokenPosition(t *testing.T) {
+	input := "let x = 5;\n  x == 10;\n\n\tfn"
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedOffset int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 0, 1, 1},
+		{token.IDENT, 4, 1, 5},
+		{token.ASSIGN, 6, 1, 7},
+		{token.INT, 8, 1, 9},
+		{token.SEMICOLON, 9, 1, 10},
+		{token.IDENT, 13, 2, 3},
+		{token.EQ, 15, 2, 5},
+		{token.INT, 18, 2, 8},
+		{token.SEMICOLON, 20, 2, 10},
+		{token.FUNCTION, 24, 4, 2},
+		{token.EOF, 26, 4, 4},
+	}
+
+	l := New(input, WithFilename("test.mk"))
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		expected := token.Position{
+			Filename: "test.mk",
+			Offset:   tt.expectedOffset,
+			Line:     tt.expectedLine,
+			Column:   tt.expectedColumn,
+		}
+		if tok.Pos != expected {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v",
+				i, expected, tok.Pos)
+		}
+	}
+}
+
+func TestNextTokenString(t *testing.T) {
+	input := `"foobar"
+	"foo bar"
+	""
+	"tab\there\nnew \"quoted\" back\\slash"
+	"caf\u{e9} \u{1F600}"
+	"bad \q escape" 1
+	"bad \u{110000}"
+	"bad \u{}"
+	"unterminated`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.STRING, ""},
+		{token.STRING, "tab\there\nnew \"quoted\" back\\slash"},
+		{token.STRING, "caf\u00e9 \U0001F600"},
+		{token.ILLEGAL, `"bad \q escape"`},
+		{token.INT, "1"},
+		{token.ILLEGAL, `"bad \u{110000}"`},
+		{token.ILLEGAL, `"bad \u{}"`},
+		{token.ILLEGAL, `"unterminated`},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringBadEscapes(t *testing.T) {
+	input := "\"fine\\n\" \"a\\qb\\z\"\n  \"\\u{110000}\" \"\\u{}\" \"\\x"
+
+	tests := []struct {
+		expectedType      token.TokenType
+		expectedBadEscape string
+		expectedPos       string
+	}{
+		{token.STRING, "", "-"},
+		{token.ILLEGAL, `\q`, "1:12"},
+		{token.ILLEGAL, `\u{110000}`, "2:4"},
+		{token.ILLEGAL, `\u{`, "2:17"},
+		{token.ILLEGAL, `\x`, "2:24"},
+		{token.EOF, "", "-"},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.BadEscape != tt.expectedBadEscape {
+			t.Errorf("tests[%d] - bad escape wrong. expected=%q, got=%q",
+				i, tt.expectedBadEscape, tok.BadEscape)
+		}
+		if tok.BadEscapePos.String() != tt.expectedPos {
+			t.Errorf("tests[%d] - bad escape position wrong. expected=%s, got=%s",
+				i, tt.expectedPos, tok.BadEscapePos)
+		}
+	}
+}
+
+func TestNextTokenBrackets(t *testing.T) {
+	input := `[1, 2][0]; {"a": 1}`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenFloat(t *testing.T) {
+	input := `3.14 0.5 10.0 1e-9 6.02E23 2e+3 7. 1.x 5e`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "10.0"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "6.02E23"},
+		{token.FLOAT, "2e+3"},
+		{token.INT, "7"},
+		{token.ILLEGAL, "."},
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "x"},
+		{token.INT, "5"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenNumberBases(t *testing.T) {
+	input := `0x1F 0o17 0b1010 1_000_000 0XfF 0xZZ 1_ 0b102 1_000.5 007 12abc`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0x1F"},
+		{token.INT, "0o17"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000_000"},
+		{token.INT, "0XfF"},
+		{token.INT, "0xZZ"},
+		{token.INT, "1_"},
+		{token.INT, "0b102"},
+		{token.FLOAT, "1_000.5"},
+		{token.INT, "007"},
+		{token.INT, "12"},
+		{token.IDENT, "abc"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenSkipsComments(t *testing.T) {
+	input := `// leading comment
+	let x = 5; // trailing comment
+	/* block */ let /* inline */ y = 10 / 2;
+	/* outer /* nested */ still comment */ x
+	//`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "y"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenEmitsComments(t *testing.T) {
+	input := "// note\nx /* a /* b */ c */ y\n/* open"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.COMMENT, "// note", 1, 1},
+		{token.IDENT, "x", 2, 1},
+		{token.COMMENT, "/* a /* b */ c */", 2, 3},
+		{token.IDENT, "y", 2, 21},
+		{token.ILLEGAL, "/* open", 3, 1},
+		{token.EOF, "", 3, 8},
+	}
+
+	l := New(input, WithComments())
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%s",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos)
+		}
+	}
+}
+
+func TestNextTokenUnicode(t *testing.T) {
+	input := "let größe = 5;\nlet 変数1 = \"héllo\";\nx2 € y\xffz ٣"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedOffset  int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 0, 1},
+		{token.IDENT, "größe", 4, 5},
+		{token.ASSIGN, "=", 12, 11},
+		{token.INT, "5", 14, 13},
+		{token.SEMICOLON, ";", 15, 14},
+		{token.LET, "let", 17, 1},
+		{token.IDENT, "変数1", 21, 5},
+		{token.ASSIGN, "=", 29, 9},
+		{token.STRING, "héllo", 31, 11},
+		{token.SEMICOLON, ";", 39, 18},
+		{token.IDENT, "x2", 41, 1},
+		{token.ILLEGAL, "€", 44, 4},
+		{token.IDENT, "y", 48, 6},
+		{token.ILLEGAL, "\xff", 49, 7},
+		{token.IDENT, "z", 50, 8},
+		{token.ILLEGAL, "٣", 52, 10},
+		{token.EOF, "", 54, 11},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Offset != tt.expectedOffset || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected offset=%d column=%d, got offset=%d column=%d",
+				i, tt.expectedOffset, tt.expectedColumn, tok.Pos.Offset, tok.Pos.Column)
+		}
+	}
+}
+
+func TestIsLetterUnicode(t *testing.T) {
+	for _, ch := range "äßжπ漢" {
+		if !isLetter(ch) {
+			t.Errorf("expected %q to be a letter", ch)
+		}
+	}
+
+	for _, ch := range "€٣ 1" {
+		if isLetter(ch) {
+			t.Errorf("expected %q not to be a letter", ch)
+		}
+	}
+}
+
+func TestNextTokenTwoCharOperators(t *testing.T) {
+	input := `a <= b >= c && d || e < f > g & h | i`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OR, "||"},
+		{token.IDENT, "e"},
+		{token.LT, "<"},
+		{token.IDENT, "f"},
+		{token.GT, ">"},
+		{token.IDENT, "g"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "h"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "i"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenArithmeticBitwise(t *testing.T) {
+	input := `a % b ** c * d ^ ~e << f >> g <<= h`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.IDENT, "c"},
+		{token.ASTERISK, "*"},
+		{token.IDENT, "d"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "e"},
+		{token.LSHIFT, "<<"},
+		{token.IDENT, "f"},
+		{token.RSHIFT, ">>"},
+		{token.IDENT, "g"},
+		{token.LSHIFT, "<<"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "h"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNewReaderMatchesNew(t *testing.T) {
+	inputs := []string{
+		"let five = 5;\nlet ten = 10.5e3;\nfn(x, y) { x + y; }",
+		"let s = \"a\\tb\\u{1F600}\";\n\"bad \\q\"",
+		"0x_ff 0b1010 1_000 /* a /* nested */ comment */ // line\n[1, 2]",
+		"let größe = 1;\nπ € x2 \xff z ٣",
+		"/* unterminated",
+	}
+
+	for _, input := range inputs {
+		want := New(input, WithFilename("a.mk"), WithComments())
+		got := NewReader(iotest.OneByteReader(strings.NewReader(input)),
+			WithFilename("a.mk"), WithComments())
+		for i := 0; ; i++ {
+			expected, tok := want.NextToken(), got.NextToken()
+			if tok != expected {
+				t.Fatalf("%q: tokens[%d] wrong. expected=%+v, got=%+v",
+					input, i, expected, tok)
+			}
+			if tok.Type == token.EOF {
+				break
+			}
+		}
+		if got.Err() != nil {
+			t.Errorf("%q: unexpected read error: %s", input, got.Err())
+		}
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(errRead))
+	l := NewReader(r)
+
+	expected := []token.TokenType{token.LET, token.IDENT, token.EOF}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+
+	if l.Err() != errRead {
+		t.Errorf("l.Err() wrong. expected=%v, got=%v", errRead, l.Err())
+	}
+}
@@ -3,9 +3,27 @@ package main
 import (
 	"fmt"
 	"monkey/repl"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		filename := os.Args[1]
+		input := os.Stdin
+		if filename != "-" {
+			f, err := os.Open(filename)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			input = f
+		}
+		if !repl.Run(filename, input, os.Stderr) {
+			os.Exit(1)
+		}
+		return
+	}
 	fmt.Printf("Enter commands to evaluate monkey language\n")
 	fmt.Println("-----------------------------------")
 	repl.Start()
@@ -0,0 +1,233 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"monkey/ast"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// BuiltinDefinition is a builtin function and the name programs call it by
+type BuiltinDefinition struct {
+	Name    string
+	Builtin *Builtin
+}
+
+var (
+	builtinsMu sync.RWMutex
+	builtins   = []BuiltinDefinition{
+		{"len", &Builtin{Fn: builtinLen}},
+		{"first", &Builtin{Fn: builtinFirst}},
+		{"last", &Builtin{Fn: builtinLast}},
+		{"rest", &Builtin{Fn: builtinRest}},
+		{"push", &Builtin{Fn: builtinPush}},
+		{"puts", &Builtin{Fn: builtinPuts}},
+		{"type", &Builtin{Fn: builtinType}},
+		{"str", &Builtin{Fn: builtinStr}},
+		{"int", &Builtin{Fn: builtinInt}},
+	}
+)
+
+// RegisterBuiltin makes fn callable from every program as name. A new name
+// is appended to the table, an existing one keeps its index and gets fn, so
+// the index of a builtin never changes once it is registered. It is safe to
+// call while programs are running.
+func RegisterBuiltin(name string, fn BuiltinFunction) {
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	for i, def := range builtins {
+		if def.Name == name {
+			builtins[i].Builtin = &Builtin{Fn: fn}
+			return
+		}
+	}
+	builtins = append(builtins, BuiltinDefinition{Name: name, Builtin: &Builtin{Fn: fn}})
+}
+
+// LookupBuiltin returns the builtin registered as name
+func LookupBuiltin(name string) (*Builtin, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	for _, def := range builtins {
+		if def.Name == name {
+			return def.Builtin, true
+		}
+	}
+	return nil, false
+}
+
+// Builtins returns a copy of the builtin table in registration order, which
+// is the order of the OpGetBuiltin indexes
+func Builtins() []BuiltinDefinition {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	defs := make([]BuiltinDefinition, len(builtins))
+	copy(defs, builtins)
+	return defs
+}
+
+// SnapshotBuiltins returns a function that restores the builtin table to
+// its current state, for tests that register builtins of their own
+func SnapshotBuiltins() (restore func()) {
+	saved := Builtins()
+	return func() {
+		builtinsMu.Lock()
+		defer builtinsMu.Unlock()
+		builtins = saved
+	}
+}
+
+func newError(format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func wrongNumberOfArguments(want int, args []Object) *Error {
+	return newError("wrong number of arguments: want=%d, got=%d", want, len(args))
+}
+
+func builtinLen(args ...Object) Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(1, args)
+	}
+	switch arg := args[0].(type) {
+	case *String:
+		return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *Array:
+		return &Integer{Value: int64(len(arg.Elements))}
+	case *Hash:
+		return &Integer{Value: int64(len(arg.Pairs))}
+	default:
+		return newError("argument to `len` not supported, got %s", args[0].Type())
+	}
+}
+
+// arrayArgument checks that a builtin taking an array got exactly that
+func arrayArgument(name string, want int, args []Object) (*Array, *Error) {
+	if len(args) != want {
+		return nil, wrongNumberOfArguments(want, args)
+	}
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	return arr, nil
+}
+
+func builtinFirst(args ...Object) Object {
+	arr, err := arrayArgument("first", 1, args)
+	if err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return nil
+	}
+	return arr.Elements[0]
+}
+
+func builtinLast(args ...Object) Object {
+	arr, err := arrayArgument("last", 1, args)
+	if err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return nil
+	}
+	return arr.Elements[len(arr.Elements)-1]
+}
+
+func builtinRest(args ...Object) Object {
+	arr, err := arrayArgument("rest", 1, args)
+	if err != nil {
+		return err
+	}
+	if len(arr.Elements) == 0 {
+		return nil
+	}
+	elements := make([]Object, len(arr.Elements)-1)
+	copy(elements, arr.Elements[1:])
+	return &Array{Elements: elements}
+}
+
+// builtinPush returns a new array, arrays are never modified in place
+func builtinPush(args ...Object) Object {
+	arr, err := arrayArgument("push", 2, args)
+	if err != nil {
+		return err
+	}
+	elements := make([]Object, len(arr.Elements), len(arr.Elements)+1)
+	copy(elements, arr.Elements)
+	return &Array{Elements: append(elements, args[1])}
+}
+
+func builtinPuts(args ...Object) Object {
+	for _, arg := range args {
+		if str, ok := arg.(*String); ok {
+			fmt.Println(str.Value)
+		} else {
+			fmt.Println(arg.Inspect())
+		}
+	}
+	return nil
+}
+
+func builtinType(args ...Object) Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(1, args)
+	}
+	return &String{Value: string(args[0].Type())}
+}
+
+func builtinStr(args ...Object) Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(1, args)
+	}
+	if str, ok := args[0].(*String); ok {
+		return str
+	}
+	return &String{Value: args[0].Inspect()}
+}
+
+func builtinInt(args ...Object) Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(1, args)
+	}
+	switch arg := args[0].(type) {
+	case *Integer, *BigInt:
+		return arg
+	case *Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("could not convert %s to INTEGER", arg.Inspect())
+		}
+		// truncates towards zero
+		value, _ := new(big.Float).SetFloat64(arg.Value).Int(nil)
+		return NormalizeBigInt(value)
+	case *Boolean:
+		if arg.Value {
+			return &Integer{Value: 1}
+		}
+		return &Integer{Value: 0}
+	case *String:
+		// follows the rules of integer literals, with an optional sign
+		s := strings.TrimSpace(arg.Value)
+		digits := strings.TrimLeft(s, "+-")
+		if len(s)-len(digits) > 1 {
+			return newError("could not convert %q to INTEGER", arg.Value)
+		}
+		value, bigValue, err := ast.ParseInteger(digits)
+		if err != nil {
+			return newError("could not convert %q to INTEGER", arg.Value)
+		}
+		if bigValue == nil {
+			bigValue = big.NewInt(value)
+		}
+		if strings.HasPrefix(s, "-") {
+			bigValue.Neg(bigValue)
+		}
+		return NormalizeBigInt(bigValue)
+	default:
+		return newError("argument to `int` not supported, got %s", args[0].Type())
+	}
+}
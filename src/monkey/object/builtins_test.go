@@ -0,0 +1,64 @@
+package object
+
+import "testing"
+
+func TestBuiltinsOrder(t *testing.T) {
+	expected := []string{"len", "first", "last", "rest", "push", "puts", "type", "str", "int"}
+
+	defs := Builtins()
+	if len(defs) != len(expected) {
+		t.Fatalf("wrong number of builtins. want=%d, got=%d", len(expected), len(defs))
+	}
+	for i, name := range expected {
+		if defs[i].Name != name {
+			t.Errorf("builtins[%d] wrong. want=%q, got=%q", i, name, defs[i].Name)
+		}
+	}
+}
+
+func TestRegisterBuiltinKeepsIndexes(t *testing.T) {
+	t.Cleanup(SnapshotBuiltins())
+	before := Builtins()
+
+	RegisterBuiltin("answer", func(args ...Object) Object { return &Integer{Value: 42} })
+	RegisterBuiltin("len", func(args ...Object) Object { return &Integer{Value: -1} })
+
+	after := Builtins()
+	if len(after) != len(before)+1 {
+		t.Fatalf("wrong number of builtins. want=%d, got=%d", len(before)+1, len(after))
+	}
+	for i, def := range before {
+		if after[i].Name != def.Name {
+			t.Errorf("builtins[%d] moved. want=%q, got=%q", i, def.Name, after[i].Name)
+		}
+	}
+	if last := after[len(after)-1]; last.Name != "answer" {
+		t.Errorf("new builtin not appended. got=%q", last.Name)
+	}
+
+	builtin, ok := LookupBuiltin("len")
+	if !ok {
+		t.Fatalf("len not found")
+	}
+	if result, ok := builtin.Fn().(*Integer); !ok || result.Value != -1 {
+		t.Errorf("len was not replaced. got=%+v", builtin.Fn())
+	}
+	if _, ok := LookupBuiltin("missing"); ok {
+		t.Errorf("missing builtin found")
+	}
+}
+
+func TestSnapshotBuiltins(t *testing.T) {
+	restore := SnapshotBuiltins()
+	RegisterBuiltin("scratch", func(args ...Object) Object { return nil })
+	RegisterBuiltin("len", func(args ...Object) Object { return nil })
+	restore()
+
+	if _, ok := LookupBuiltin("scratch"); ok {
+		t.Errorf("scratch still registered after restore")
+	}
+	builtin, _ := LookupBuiltin("len")
+	if result, ok := builtin.Fn(&String{Value: "ab"}).(*Integer); !ok || result.Value != 2 {
+		t.Errorf("len not restored. got=%+v", builtin.Fn(&String{Value: "ab"}))
+	}
+}
@@ -0,0 +1,36 @@
+package object
+
+// Environment holds the bindings of a scope, falling back to its outer
+// scope for names it does not define
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+}
+
+// NewEnvironment creates an empty top level Environment
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, outer: nil}
+}
+
+// NewEnclosedEnvironment creates an empty Environment nested in outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get returns the object bound to name in this or any enclosing scope
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// Set binds val to name in this scope
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
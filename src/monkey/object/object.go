@@ -0,0 +1,333 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"monkey/ast"
+	"monkey/code"
+	"monkey/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ObjectType identifies the runtime type of an Object
+type ObjectType string
+
+const (
+	// INTEGER_OBJ type for integers
+	INTEGER_OBJ = "INTEGER"
+	// BIGINT_OBJ type for integers that do not fit in an Integer
+	BIGINT_OBJ = "BIGINT"
+	// FLOAT_OBJ type for floating point numbers
+	FLOAT_OBJ = "FLOAT"
+	// STRING_OBJ type for strings
+	STRING_OBJ = "STRING"
+	// BOOLEAN_OBJ type for booleans
+	BOOLEAN_OBJ = "BOOLEAN"
+	// NULL_OBJ type for null
+	NULL_OBJ = "NULL"
+	// RETURN_VALUE_OBJ type for wrapped return values
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	// ERROR_OBJ type for runtime errors
+	ERROR_OBJ = "ERROR"
+	// FUNCTION_OBJ type for user defined functions
+	FUNCTION_OBJ = "FUNCTION"
+	// BUILTIN_OBJ type for functions implemented in Go
+	BUILTIN_OBJ = "BUILTIN"
+	// COMPILED_FUNCTION_OBJ type for functions compiled to bytecode
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	// ARRAY_OBJ type for arrays
+	ARRAY_OBJ = "ARRAY"
+	// HASH_OBJ type for hash maps
+	HASH_OBJ = "HASH"
+)
+
+// Object interface for every runtime value
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// HashKey identifies a Hashable value inside a Hash
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by objects usable as hash keys
+type Hashable interface {
+	HashKey() HashKey
+}
+
+// Integer type
+type Integer struct {
+	Value int64
+}
+
+// Type implementation for Integer
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+
+// Inspect implementation for Integer
+func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
+
+// HashKey implementation for Integer
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// BigInt type, only used for values outside the int64 range
+type BigInt struct {
+	Value *big.Int
+}
+
+// NormalizeBigInt returns value as an Integer when it fits in int64 and as
+// a BigInt otherwise, keeping the Integer fast path
+func NormalizeBigInt(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInt{Value: value}
+}
+
+// Type implementation for BigInt
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+
+// Inspect implementation for BigInt
+func (b *BigInt) Inspect() string { return b.Value.String() }
+
+// HashKey implementation for BigInt
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(b.Value.String()))
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// Float type
+type Float struct {
+	Value float64
+}
+
+// Type implementation for Float
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect implementation for Float, always showing a fraction or exponent
+// so that floats stay distinguishable from integers
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// String type
+type String struct {
+	Value string
+}
+
+// Type implementation for String
+func (s *String) Type() ObjectType { return STRING_OBJ }
+
+// Inspect implementation for String
+func (s *String) Inspect() string { return s.Value }
+
+// HashKey implementation for String
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// Boolean type
+type Boolean struct {
+	Value bool
+}
+
+// Type implementation for Boolean
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+
+// Inspect implementation for Boolean
+func (b *Boolean) Inspect() string { return fmt.Sprintf("%t", b.Value) }
+
+// HashKey implementation for Boolean
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+// Null type
+type Null struct{}
+
+// Type implementation for Null
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+
+// Inspect implementation for Null
+func (n *Null) Inspect() string { return "null" }
+
+// ReturnValue wraps the value of a return statement
+type ReturnValue struct {
+	Value Object
+}
+
+// Type implementation for ReturnValue
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+
+// Inspect implementation for ReturnValue
+func (rv *ReturnValue) Inspect() string { return rv.Value.Inspect() }
+
+// Error type
+type Error struct {
+	Message string
+	Pos     token.Position // where evaluation failed
+	Stack   []Frame        // the calls that were active, innermost first
+}
+
+// Frame is a call of a function that had not returned when an error occurred
+type Frame struct {
+	Function string         // name of the called function
+	Call     token.Position // position of the call expression
+}
+
+// Type implementation for Error
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+
+// Inspect implementation for Error
+func (e *Error) Inspect() string { return "ERROR: " + e.Message }
+
+// Trace returns the message followed by one line per active function,
+// innermost first, giving the position reached in it:
+//
+//	ERROR: type mismatch: INTEGER + BOOLEAN
+//		file.mk:2:12 in add()
+//		file.mk:5:1 in main
+func (e *Error) Trace() string {
+	var out bytes.Buffer
+	out.WriteString(e.Inspect())
+	pos := e.Pos
+	for _, frame := range e.Stack {
+		fmt.Fprintf(&out, "\n\t%s in %s()", pos, frame.Function)
+		pos = frame.Call
+	}
+	if pos.IsValid() {
+		fmt.Fprintf(&out, "\n\t%s in main", pos)
+	}
+	return out.String()
+}
+
+// Function type, a function literal closed over the environment it was
+// defined in
+type Function struct {
+	Name       string // empty for anonymous functions
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+// Type implementation for Function
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+
+// Inspect implementation for Function
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	if f.Body != nil {
+		out.WriteString(f.Body.String())
+	}
+	out.WriteString("\n}")
+	return out.String()
+}
+
+// BuiltinFunction is the Go signature of a builtin, returning nil for null
+type BuiltinFunction func(args ...Object) Object
+
+// Builtin type
+type Builtin struct {
+	Fn BuiltinFunction
+}
+
+// Type implementation for Builtin
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+
+// Inspect implementation for Builtin
+func (b *Builtin) Inspect() string { return "builtin function" }
+
+// CompiledFunction type, the bytecode of a function literal
+type CompiledFunction struct {
+	Name          string // empty for anonymous functions
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+}
+
+// Type implementation for CompiledFunction
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+
+// Inspect implementation for CompiledFunction, giving name and arity
+func (cf *CompiledFunction) Inspect() string {
+	name := cf.Name
+	if name == "" {
+		name = "fn"
+	}
+	return fmt.Sprintf("CompiledFunction[%s/%d]", name, cf.NumParameters)
+}
+
+// Array type
+type Array struct {
+	Elements []Object
+}
+
+// Type implementation for Array
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+
+// Inspect implementation for Array
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+// HashPair keeps the original key next to its value
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash type
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+// Type implementation for Hash
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+
+// Inspect implementation for Hash, with pairs sorted for stable output
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+	sort.Strings(pairs)
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
@@ -0,0 +1,157 @@
+package object
+
+import (
+	"math/big"
+	"monkey/ast"
+	"monkey/token"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		obj          Object
+		expectedType ObjectType
+		expected     string
+	}{
+		{&Integer{Value: -42}, INTEGER_OBJ, "-42"},
+		{&BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 64)}, BIGINT_OBJ, "18446744073709551616"},
+		{&Float{Value: 3.25}, FLOAT_OBJ, "3.25"},
+		{&Float{Value: 2}, FLOAT_OBJ, "2.0"},
+		{&Float{Value: 1e21}, FLOAT_OBJ, "1e+21"},
+		{&String{Value: "hello"}, STRING_OBJ, "hello"},
+		{&Boolean{Value: true}, BOOLEAN_OBJ, "true"},
+		{&Null{}, NULL_OBJ, "null"},
+		{&ReturnValue{Value: &Integer{Value: 5}}, RETURN_VALUE_OBJ, "5"},
+		{&Error{Message: "boom"}, ERROR_OBJ, "ERROR: boom"},
+		{
+			&Function{Parameters: []*ast.Identifier{
+				{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"},
+				{Token: token.Token{Type: token.IDENT, Literal: "y"}, Value: "y"},
+			}},
+			FUNCTION_OBJ,
+			"fn(x, y) {\n\n}",
+		},
+		{&Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, ARRAY_OBJ, "[1, a]"},
+		{
+			&Hash{Pairs: map[HashKey]HashPair{
+				(&String{Value: "b"}).HashKey(): {Key: &String{Value: "b"}, Value: &Integer{Value: 2}},
+				(&String{Value: "a"}).HashKey(): {Key: &String{Value: "a"}, Value: &Integer{Value: 1}},
+			}},
+			HASH_OBJ,
+			"{a: 1, b: 2}",
+		},
+		{&Builtin{Fn: func(args ...Object) Object { return nil }}, BUILTIN_OBJ, "builtin function"},
+		{&CompiledFunction{Name: "add", NumParameters: 2}, COMPILED_FUNCTION_OBJ, "CompiledFunction[add/2]"},
+		{&CompiledFunction{}, COMPILED_FUNCTION_OBJ, "CompiledFunction[fn/0]"},
+	}
+
+	for i, tt := range tests {
+		if tt.obj.Type() != tt.expectedType {
+			t.Errorf("tests[%d] - type wrong. expected=%q, got=%q", i, tt.expectedType, tt.obj.Type())
+		}
+		if tt.obj.Inspect() != tt.expected {
+			t.Errorf("tests[%d] - inspect wrong. expected=%q, got=%q", i, tt.expected, tt.obj.Inspect())
+		}
+	}
+}
+
+func TestEnclosedEnvironment(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("a", &Integer{Value: 1})
+	outer.Set("b", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("b", &Integer{Value: 3})
+	inner.Set("c", &Integer{Value: 4})
+
+	tests := []struct {
+		env      *Environment
+		name     string
+		expected int64
+		found    bool
+	}{
+		{inner, "a", 1, true},
+		{inner, "b", 3, true},
+		{inner, "c", 4, true},
+		{outer, "b", 2, true},
+		{outer, "c", 0, false},
+	}
+
+	for _, tt := range tests {
+		obj, ok := tt.env.Get(tt.name)
+		if ok != tt.found {
+			t.Errorf("Get(%q) found=%t, expected %t", tt.name, ok, tt.found)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		integer, ok := obj.(*Integer)
+		if !ok {
+			t.Errorf("Get(%q) not *Integer. got=%T", tt.name, obj)
+			continue
+		}
+		if integer.Value != tt.expected {
+			t.Errorf("Get(%q) wrong value. expected=%d, got=%d", tt.name, tt.expected, integer.Value)
+		}
+	}
+}
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+	diff2 := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if diff1.HashKey() != diff2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+}
+
+func TestHashKeyTypes(t *testing.T) {
+	one := &Integer{Value: 1}
+	yes := &Boolean{Value: true}
+
+	if one.HashKey() == yes.HashKey() {
+		t.Errorf("integer 1 and true have the same hash key")
+	}
+}
+
+func TestErrorTrace(t *testing.T) {
+	pos := func(line, column int) token.Position {
+		return token.Position{Filename: "a.mk", Line: line, Column: column}
+	}
+
+	tests := []struct {
+		err      *Error
+		expected string
+	}{
+		{&Error{Message: "boom"}, "ERROR: boom"},
+		{&Error{Message: "boom", Pos: pos(3, 7)}, "ERROR: boom\n\ta.mk:3:7 in main"},
+		{
+			&Error{
+				Message: "boom",
+				Pos:     pos(2, 5),
+				Stack: []Frame{
+					{Function: "add", Call: pos(6, 3)},
+					{Function: "fn", Call: pos(9, 1)},
+				},
+			},
+			"ERROR: boom\n\ta.mk:2:5 in add()\n\ta.mk:6:3 in fn()\n\ta.mk:9:1 in main",
+		},
+	}
+
+	for _, tt := range tests {
+		if trace := tt.err.Trace(); trace != tt.expected {
+			t.Errorf("wrong trace. want=%q, got=%q", tt.expected, trace)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/token"
+)
+
+// ErrorCode classifies a ParseError
+type ErrorCode int
+
+const (
+	_ ErrorCode = iota
+	// ErrUnexpectedToken is reported when a specific token was expected
+	ErrUnexpectedToken
+	// ErrNoPrefixParseFn is reported when a token cannot start an expression
+	ErrNoPrefixParseFn
+	// ErrInvalidInteger is reported for integer literals that do not parse
+	ErrInvalidInteger
+	// ErrInvalidBoolean is reported for boolean literals that do not parse
+	ErrInvalidBoolean
+	// ErrIllegalToken is reported for input the lexer could not tokenize
+	ErrIllegalToken
+	// ErrInvalidFloat is reported for float literals that do not parse
+	ErrInvalidFloat
+	// ErrInvalidEscape is reported for string literals with an invalid
+	// escape sequence
+	ErrInvalidEscape
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrUnexpectedToken: "UnexpectedToken",
+	ErrNoPrefixParseFn: "NoPrefixParseFn",
+	ErrInvalidInteger:  "InvalidInteger",
+	ErrInvalidBoolean:  "InvalidBoolean",
+	ErrIllegalToken:    "IllegalToken",
+	ErrInvalidFloat:    "InvalidFloat",
+	ErrInvalidEscape:   "InvalidEscape",
+}
+
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+// ParseError describes a single syntax error
+type ParseError struct {
+	Pos      token.Position
+	Code     ErrorCode
+	Expected []token.TokenType // token types that would have been accepted, if known
+	Actual   token.Token       // the offending token
+	Msg      string
+}
+
+// Error implementation for ParseError, formatted as position: message
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is the list of errors found while parsing a program
+type ErrorList []*ParseError
+
+// Error implementation for ErrorList
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns nil for an empty list and the list itself otherwise
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -6,17 +6,26 @@ import (
 	"monkey/lexer"
 	"monkey/token"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	OR
+	AND
 	EQUALS
 	LESSGREATER
+	BITOR
+	BITXOR
+	BITAND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER // binds tighter than prefix operators, so -2 ** 2 is -(2 ** 2)
 	CALL
+	INDEX
 )
 
 var precedeneces = map[token.TokenType]int{
@@ -24,10 +33,23 @@ var precedeneces = map[token.TokenType]int{
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LT_EQ:    LESSGREATER,
+	token.GT_EQ:    LESSGREATER,
+	token.AND:      AND,
+	token.OR:       OR,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POWER:    POWER,
+	token.BIT_OR:   BITOR,
+	token.BIT_XOR:  BITXOR,
+	token.BIT_AND:  BITAND,
+	token.LSHIFT:   SHIFT,
+	token.RSHIFT:   SHIFT,
+	token.LPAREN:   CALL,
+	token.LBRACKET: INDEX,
 }
 
 type (
@@ -38,7 +60,13 @@ type (
 // Parser strut
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors ErrorList
+
+	// panicking is set by the first error of a statement and suppresses
+	// the errors that follow it until ParseProgram has synchronized
+	panicking bool
+	// depth counts the braces opened and not yet closed up to curToken
+	depth int
 
 	curToken  token.Token
 	peekToken token.Token
@@ -51,16 +79,26 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: ErrorList{},
 	}
 	p.prefixParserFns = make(map[token.TokenType]prefixParserFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	// blocks are only parsed after if and fn, so { in expression position
+	// always starts a hash literal
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 
 	p.infixParserFns = make(map[token.TokenType]infixParserFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -71,6 +109,19 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.LSHIFT, p.parseInfixExpression)
+	p.registerInfix(token.RSHIFT, p.parseInfixExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -78,19 +129,63 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-// Errors for parser implementation
-func (p *Parser) Errors() []string {
+// Errors returns the syntax errors found by ParseProgram
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected a %s token, but got %s", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.unexpectedTokenError(p.peekToken, t)
+}
+
+// unexpectedTokenError records that actual is none of the expected tokens
+func (p *Parser) unexpectedTokenError(actual token.Token, expected ...token.TokenType) {
+	names := make([]string, len(expected))
+	for i, t := range expected {
+		names[i] = string(t)
+	}
+	p.addError(&ParseError{
+		Pos:      actual.Pos,
+		Code:     ErrUnexpectedToken,
+		Expected: expected,
+		Actual:   actual,
+		Msg:      fmt.Sprintf("expected a %s token, but got %s", strings.Join(names, " or "), actual.Type),
+	})
+}
+
+// errorf records an error about the given token
+func (p *Parser) errorf(tok token.Token, code ErrorCode, format string, args ...interface{}) {
+	p.addError(&ParseError{
+		Pos:    tok.Pos,
+		Code:   code,
+		Actual: tok,
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (p *Parser) addError(err *ParseError) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	p.errors = append(p.errors, err)
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.depth++
+	case token.RBRACE:
+		if p.depth > 0 {
+			p.depth--
+		}
+	}
 	p.peekToken = p.l.NextToken()
+	// comments are only emitted by lexers created with lexer.WithComments
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
 }
 
 // ParseProgram to return ast.Program Node of the Parser
@@ -100,7 +195,10 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 	for p.curToken.Type != token.EOF {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if p.panicking {
+			p.synchronize()
+			p.panicking = false
+		} else if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
@@ -108,6 +206,32 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// synchronize skips the rest of a broken top level statement, stopping on
+// the token that ends it or just before the keyword that starts the next
+// one. Tokens inside braces the statement opened are skipped, so that an
+// error in a function body does not end the statement at the body's first
+// ';' and a '}' only ends it when it closes the statement's outermost brace.
+func (p *Parser) synchronize() {
+	for !p.currentTokenIs(token.EOF) {
+		if p.depth == 0 {
+			if p.currentTokenIs(token.SEMICOLON) {
+				return
+			}
+			if p.currentTokenIs(token.RBRACE) {
+				// the ';' after a closing brace belongs to the same statement
+				if p.peekTokenIs(token.SEMICOLON) {
+					p.nextToken()
+				}
+				return
+			}
+			if p.peekTokenIs(token.LET) || p.peekTokenIs(token.RETURN) {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
@@ -126,6 +250,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	if ie.Token.Type == token.POWER {
+		// right-associative: 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		precedence--
+	}
 	p.nextToken()
 	ie.Right = p.parseExpression(precedence)
 	return ie
@@ -146,7 +274,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	}
 	leftExp := prefix()
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() && !p.panicking {
 		infix := p.infixParserFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -160,20 +288,237 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 func (p *Parser) parseBoolean() ast.Expression {
 	b, err := strconv.ParseBool(p.curToken.Literal)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as an boolean", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken, ErrInvalidBoolean, "could not parse %q as an boolean", p.curToken.Literal)
 	}
 	return &ast.Boolean{Token: p.curToken, Value: b}
 }
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
-	i64, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
+	lit := &ast.IntegerLiteral{Token: p.curToken}
+
+	value, bigValue, err := ast.ParseInteger(p.curToken.Literal)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as an interger", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken, ErrInvalidInteger, "could not parse %q as an integer: %s", p.curToken.Literal, err)
+		return lit
+	}
+	lit.Value, lit.Big = value, bigValue
+	return lit
+}
+
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Consequence = p.parseBlockStatement()
+
+	if !p.peekTokenIs(token.ELSE) {
+		return expression
+	}
+	p.nextToken()
+
+	// else if chains nest the following if expression in the alternative block
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		block := &ast.BlockStatement{Token: p.curToken}
+		stmt := p.parseExpressionStatement()
+		if stmt.Expression == nil {
+			return nil
+		}
+		block.Statements = []ast.Statement{stmt}
+		expression.Alternative = block
+		return expression
 	}
-	return &ast.IntegerLiteral{Token: p.curToken, Value: i64}
 
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Alternative = p.parseBlockStatement()
+	return expression
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+	for !p.currentTokenIs(token.RBRACE) && !p.currentTokenIs(token.EOF) {
+		stmt := p.parseStatement()
+		if p.panicking {
+			// leave recovery to ParseProgram, which knows the statement's extent
+			return block
+		}
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	if !p.currentTokenIs(token.RBRACE) {
+		p.unexpectedTokenError(p.curToken, token.RBRACE)
+	}
+	return block
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	lit.Body = p.parseBlockStatement()
+	return lit
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return identifiers
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	return exp
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = []ast.HashPair{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+		if p.panicking || !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		if p.panicking {
+			return nil
+		}
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		} else if !p.peekTokenIs(token.RBRACE) {
+			p.unexpectedTokenError(p.peekToken, token.COMMA, token.RBRACE)
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return exp
+}
+
+// parseExpressionList parses comma separated expressions up to end
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) && !p.panicking {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+	if p.panicking {
+		return nil
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+	return list
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	f64, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorf(p.curToken, ErrInvalidFloat, "could not parse %q as a float", p.curToken.Literal)
+	}
+	return &ast.FloatLiteral{Token: p.curToken, Value: f64}
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
@@ -181,8 +526,20 @@ func (p *Parser) parseIdentifier() ast.Expression {
 }
 
 func (p *Parser) noPrefixParserFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	if t == token.ILLEGAL && p.curToken.BadEscape != "" {
+		p.addError(&ParseError{
+			Pos:    p.curToken.BadEscapePos,
+			Code:   ErrInvalidEscape,
+			Actual: p.curToken,
+			Msg:    fmt.Sprintf("invalid escape sequence %s", p.curToken.BadEscape),
+		})
+		return
+	}
+	if t == token.ILLEGAL {
+		p.errorf(p.curToken, ErrIllegalToken, "illegal token %q", p.curToken.Literal)
+		return
+	}
+	p.errorf(p.curToken, ErrNoPrefixParseFn, "no prefix parse function for %s found", t)
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
@@ -197,8 +554,19 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		return stmt
+	}
+	if p.peekTokenIs(token.EOF) || p.peekTokenIs(token.RBRACE) {
+		return stmt
+	}
+
 	p.nextToken()
-	for !p.currentTokenIs(token.SEMICOLON) {
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -216,7 +584,13 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	for !p.currentTokenIs(token.SEMICOLON) {
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"monkey/ast"
 	"monkey/lexer"
+	"monkey/token"
 	"testing"
 )
 
@@ -106,6 +107,118 @@ func TestOperatorPrececenceParsing(t *testing.T) {
 			"3 < 5 == true",
 			"((3 < 5) == true)",
 		},
+		{
+			"1 + (2 + 3) + 4",
+			"((1 + (2 + 3)) + 4)",
+		},
+		{
+			"(5 + 5) * 2",
+			"((5 + 5) * 2)",
+		},
+		{
+			"2 / (5 + 5)",
+			"(2 / (5 + 5))",
+		},
+		{
+			"-(5 + 5)",
+			"(-(5 + 5))",
+		},
+		{
+			"!(true == true)",
+			"(!(true == true))",
+		},
+		{
+			"(1 + 2) * 3",
+			"((1 + 2) * 3)",
+		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"f(x)[0]",
+			"(f(x)[0])",
+		},
+		{
+			"-1.5 * 2 + 0.5",
+			"(((-1.5) * 2) + 0.5)",
+		},
+		{
+			"a <= b == b >= c",
+			"((a <= b) == (b >= c))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == b && c != d",
+			"((a == b) && (c != d))",
+		},
+		{
+			"!a && b < c + 1",
+			"((!a) && (b < (c + 1)))",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"a ** b ** c",
+			"(a ** (b ** c))",
+		},
+		{
+			"-a ** b",
+			"(-(a ** b))",
+		},
+		{
+			"a * b ** -c",
+			"(a * (b ** (-c)))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"((a & b) == c)",
+		},
+		{
+			"a << b + c >> d",
+			"((a << (b + c)) >> d)",
+		},
+		{
+			"a & b << c",
+			"(a & (b << c))",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
+		{
+			"a[0] ** 2",
+			"((a[0]) ** 2)",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -135,7 +248,18 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ** 5;", 5, "**", 5},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
 		{"true == true", true, "==", true},
+		{"true && false", true, "&&", false},
+		{"false || true", false, "||", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
 	}
@@ -170,6 +294,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 	}{
 		{"!5;", "!", 5},
 		{"-15;", "-", 15},
+		{"~15;", "~", 15},
 		{"!true", "!", true},
 		{"!false", "!", false},
 	}
@@ -300,8 +425,9 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	return true
 }
 
-func TestIntegerLiteralExpression(t *testing.T) {
-	input := "5;"
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
@@ -316,138 +442,1090 @@ func TestIntegerLiteralExpression(t *testing.T) {
 		t.Fatalf("program.Statements[0] is not ast.ExpressionStatments, got %T", program.Statements[0])
 	}
 
-	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
 	if !ok {
-		t.Fatalf("exp not *ast.IntetegerLiteral. got %T", stmt.Expression)
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
 	}
 
-	if literal.Value != 5 {
-		t.Errorf("literal.Value not %d. got %d", 5, literal.Value)
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
 	}
 
-	if literal.TokenLiteral() != "5" {
-		t.Errorf("literal.TokenLiteral not %s. got %s", "5", literal.TokenLiteral())
+	if len(exp.Consequence.Statements) != 1 {
+		t.Fatalf("consequence is not 1 statement. got=%d", len(exp.Consequence.Statements))
+	}
+
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative was not nil. got=%+v", exp.Alternative)
 	}
 }
 
-func TestIdentifierExpression(t *testing.T) {
-	input := "foobar;"
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { y }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if exp.Alternative == nil || len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("alternative is not 1 statement. got=%+v", exp.Alternative)
+	}
+
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	}
+
+	if !testIdentifier(t, alternative.Expression, "y") {
+		return
+	}
+
+	if program.String() != "if(x < y) xelse y" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else if (x > y) { y } else { 0 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp := stmt.Expression.(*ast.IfExpression)
+
+	if exp.Alternative == nil || len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("alternative is not 1 statement. got=%+v", exp.Alternative)
+	}
+
+	nested, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	}
+
+	elseIf, ok := nested.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("alternative is not ast.IfExpression. got=%T", nested.Expression)
+	}
+
+	if !testInfixExpression(t, elseIf.Condition, "x", ">", "y") {
+		return
+	}
+
+	if elseIf.Alternative == nil {
+		t.Fatalf("elseIf.Alternative is nil")
+	}
+
+	if program.String() != "if(x < y) xelse if(x > y) yelse 0" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestUnterminatedBlockStatement(t *testing.T) {
+	l := lexer.New("if (x) { x")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error. got=%d (%v)", len(errors), errors)
+	}
+
+	if errors[0].Error() != "1:11: expected a } token, but got EOF" {
+		t.Errorf("wrong error. got=%q", errors[0])
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
+
 	if len(program.Statements) != 1 {
 		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
 	}
+
 	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
 	if !ok {
 		t.Fatalf("program.Statements[0] is not ast.ExpressionStatments, got %T", program.Statements[0])
 	}
 
-	ident, ok := stmt.Expression.(*ast.Identifier)
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
 	if !ok {
-		t.Fatalf("exp not *ast.Identifier. got %T", stmt.Expression)
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
 	}
 
-	if ident.Value != "foobar" {
-		t.Errorf("ident.Value not %s. got %s", "foobar", ident.Value)
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(function.Parameters))
 	}
 
-	if ident.TokenLiteral() != "foobar" {
-		t.Errorf("ident.TokenLiteral not %s. got %s", "foobar", ident.TokenLiteral())
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statement. got=%d", len(function.Body.Statements))
 	}
+
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T", function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
-func TestReturnStatement(t *testing.T) {
-	input := `
-	return 5;
-	return 10;
-	return 993322;
-	`
+func TestFunctionLiteralName(t *testing.T) {
+	input := `let add = fn(x, y) { x + y; }; let apply = fn(f) { f(1) }; apply(fn(x) { x })`
+
 	l := lexer.New(input)
 	p := New(l)
-
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 
 	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements, got=%d", len(program.Statements))
+		t.Fatalf("program Statements not equal to 3, got %d", len(program.Statements))
 	}
 
-	for _, stmt := range program.Statements {
-		returnStmt, ok := stmt.(*ast.ReturnStatement)
+	for i, name := range []string{"add", "apply"} {
+		stmt := program.Statements[i].(*ast.LetStatement)
+		function, ok := stmt.Value.(*ast.FunctionLiteral)
 		if !ok {
-			t.Errorf("stmt not *ast.ReturnStatement. got=%T", stmt)
-			continue
+			t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
 		}
-
-		if returnStmt.TokenLiteral() != "return" {
-			t.Errorf("returnStmt.TokenLiteral not 'return'. got %q", returnStmt.TokenLiteral())
+		if function.Name != name {
+			t.Errorf("function.Name wrong. want=%q, got=%q", name, function.Name)
 		}
 	}
+
+	call := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if function := call.Arguments[0].(*ast.FunctionLiteral); function.Name != "" {
+		t.Errorf("anonymous function has a name. got=%q", function.Name)
+	}
 }
 
-func TestLetStatements(t *testing.T) {
-	input := `
-	let x = 5;
-	let y = 10;
-	let foobar = 838383;
-	`
+func TestNodePositions(t *testing.T) {
+	input := "let x = 1;\nadd(x, -2) * [3][0]"
 
 	l := lexer.New(input)
 	p := New(l)
-
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
+	stmt := program.Statements[1].(*ast.ExpressionStatement)
+	infix := stmt.Expression.(*ast.InfixExpression)
+	call := infix.Left.(*ast.CallExpression)
+	index := infix.Right.(*ast.IndexExpression)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "1:1"},
+		{program.Statements[0], "1:1"},
+		{program.Statements[0].(*ast.LetStatement).Value, "1:9"},
+		{stmt, "2:1"},
+		{infix, "2:12"},
+		{call, "2:1"},
+		{call.Arguments[1], "2:8"},
+		{index, "2:17"},
+		{index.Left, "2:14"},
 	}
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contains 3 statesments. got=%d", len(program.Statements))
+	for i, tt := range tests {
+		if pos := tt.node.Pos().String(); pos != tt.expected {
+			t.Errorf("tests[%d] %q - position wrong. want=%s, got=%s",
+				i, tt.node, tt.expected, pos)
+		}
 	}
+}
 
+func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input          string
+		expectedParams []string
 	}{
-		{"x"},
-		{"y"},
-		{"foobar"},
+		{"fn() {};", []string{}},
+		{"fn(x) {};", []string{"x"}},
+		{"fn(x, y, z) {};", []string{"x", "y", "z"}},
 	}
 
-	for i, tt := range tests {
-		stmt := program.Statements[i]
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
 
-		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
-			return
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
 		}
 	}
 }
 
-func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
 
-	if s.TokenLiteral() != "let" {
-		t.Errorf("s.TokenLiteral is not 'let', got=%q", s.TokenLiteral())
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
 	}
 
-	letStmt, ok := s.(*ast.LetStatement)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
 	if !ok {
-		t.Errorf("s not *ast.LetStatement. got=%T", s)
-		return false
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatments, got %T", program.Statements[0])
 	}
 
-	if letStmt.Name.Value != name {
-		t.Errorf("letStmt.Name.Value is not '%s'. got=%s", name, letStmt.Name.Value)
-		return false
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T", stmt.Expression)
 	}
 
-	if letStmt.Name.TokenLiteral() != name {
-		t.Errorf("letStmt.Name.TokenLiteral not '%s', got=%s",
-			name, letStmt.Name.TokenLiteral())
-		return false
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestHigherOrderFunctionParsing(t *testing.T) {
+	input := `let add = fn(a, b) { a + b }; add(1, 2)`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := "let add = fn(a, b) (a + b);add(1, 2)"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"0.5", 0.5},
+		{"1e-9", 1e-9},
+		{"6.02E23", 6.02e23},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got %T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got %g", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestInvalidFloatLiteral(t *testing.T) {
+	l := lexer.New("1e+")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error. got=%d (%v)", len(errors), errors)
+	}
+
+	if errors[0].Code != ErrInvalidFloat {
+		t.Errorf("wrong error code. expected=%s, got=%s", ErrInvalidFloat, errors[0].Code)
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello\tworld";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got %T", stmt.Expression)
+	}
+
+	if literal.Value != "hello\tworld" {
+		t.Errorf("literal.Value not %q. got %q", "hello\tworld", literal.Value)
+	}
+}
+
+func TestIllegalTokenError(t *testing.T) {
+	tests := []struct {
+		input    string
+		code     ErrorCode
+		expected string
+	}{
+		{`let s = "bad \q";`, ErrInvalidEscape, `1:14: invalid escape sequence \q`},
+		{`let s = "ok \n" + "\u{110000} \q";`, ErrInvalidEscape, `1:20: invalid escape sequence \u{110000}`},
+		{`let s = "unterminated`, ErrIllegalToken, `1:9: illegal token "\"unterminated"`},
+		{`let s = 1 @ 2;`, ErrIllegalToken, `1:11: illegal token "@"`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected 1 error. got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Code != tt.code {
+			t.Errorf("%q: wrong error code. expected=%s, got=%s", tt.input, tt.code, errors[0].Code)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingEmptyArrayLiterals(t *testing.T) {
+	input := "[]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) not 0. got=%d", len(array.Elements))
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+
+		if literal.Value != expected[i].key {
+			t.Errorf("key wrong. expected=%q, got=%q", expected[i].key, literal.Value)
+		}
+
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, "{}"},
+		{`{"one": 0 + 1, "two": 10 - 8}`, "{one:(0 + 1), two:(10 - 8)}"},
+		{`{1: true, false: [1, 2], x: {}}`, "{1:true, false:[1, 2], x:{}}"},
+		{`let h = {"a": 1,}; h["a"]`, "let h = {a:1};(h[a])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestParsingHashLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedTokens []token.TokenType
+	}{
+		{`{"one" 1}`, "1:8: expected a : token, but got INT", []token.TokenType{token.COLON}},
+		{`{"one": 1 "two": 2}`, "1:11: expected a , or } token, but got STRING",
+			[]token.TokenType{token.COMMA, token.RBRACE}},
+		{`{1: 2`, "1:6: expected a , or } token, but got EOF", []token.TokenType{token.COMMA, token.RBRACE}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q. got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+		if fmt.Sprint(errors[0].Expected) != fmt.Sprint(tt.expectedTokens) {
+			t.Errorf("wrong expected tokens. expected=%v, got=%v", tt.expectedTokens, errors[0].Expected)
+		}
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestIntegerLiteralExpression(t *testing.T) {
+	input := "5;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatments, got %T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntetegerLiteral. got %T", stmt.Expression)
+	}
+
+	if literal.Value != 5 {
+		t.Errorf("literal.Value not %d. got %d", 5, literal.Value)
+	}
+
+	if literal.TokenLiteral() != "5" {
+		t.Errorf("literal.TokenLiteral not %s. got %s", "5", literal.TokenLiteral())
+	}
+}
+
+func TestIntegerLiteralBases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0x1F", 31},
+		{"0XfF", 255},
+		{"0o17", 15},
+		{"0O7", 7},
+		{"0b1010", 10},
+		{"0B1", 1},
+		{"1_000_000", 1000000},
+		{"0x_FF_FF", 65535},
+		{"0b1111_0000", 240},
+		{"007", 7},
+		{"0", 0},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got %T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("%s: literal.Value not %d. got %d", tt.input, tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestBigIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FF", "4722366482869645213695"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got %T", stmt.Expression)
+		}
+
+		if literal.Big == nil {
+			t.Fatalf("%s: literal.Big is nil", tt.input)
+		}
+
+		if literal.Big.String() != tt.expected {
+			t.Errorf("literal.Big not %s. got %s", tt.expected, literal.Big)
+		}
+	}
+}
+
+func TestMalformedIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0xZZ", `1:1: could not parse "0xZZ" as an integer: invalid digit 'Z' in hexadecimal literal`},
+		{"0b102", `1:1: could not parse "0b102" as an integer: invalid digit '2' in binary literal`},
+		{"0o8", `1:1: could not parse "0o8" as an integer: invalid digit '8' in octal literal`},
+		{"0x", `1:1: could not parse "0x" as an integer: hexadecimal literal has no digits`},
+		{"1_000_", `1:1: could not parse "1_000_" as an integer: '_' must separate successive digits`},
+		{"1__000", `1:1: could not parse "1__000" as an integer: '_' must separate successive digits`},
+		{"0x__1", `1:1: could not parse "0x__1" as an integer: '_' must separate successive digits`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q. got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Code != ErrInvalidInteger {
+			t.Errorf("wrong error code. expected=%s, got=%s", ErrInvalidInteger, errors[0].Code)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestIdentifierExpression(t *testing.T) {
+	input := "foobar;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program Statements not equal to 1, got %d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatments, got %T", program.Statements[0])
+	}
+
+	ident, ok := stmt.Expression.(*ast.Identifier)
+	if !ok {
+		t.Fatalf("exp not *ast.Identifier. got %T", stmt.Expression)
+	}
+
+	if ident.Value != "foobar" {
+		t.Errorf("ident.Value not %s. got %s", "foobar", ident.Value)
+	}
+
+	if ident.TokenLiteral() != "foobar" {
+		t.Errorf("ident.TokenLiteral not %s. got %s", "foobar", ident.TokenLiteral())
+	}
+}
+
+func TestReturnStatement(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return 5;", 5},
+		{"return 10", 10},
+		{"return true;", true},
+		{"return foobar;", "foobar"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		returnStmt, ok := program.Statements[0].(*ast.ReturnStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ReturnStatement. got=%T", program.Statements[0])
+		}
+
+		if returnStmt.TokenLiteral() != "return" {
+			t.Errorf("returnStmt.TokenLiteral not 'return'. got %q", returnStmt.TokenLiteral())
+		}
+
+		if !testLiteralExpression(t, returnStmt.ReturnValue, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestBareReturnStatement(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCount int
+		expected      string
+	}{
+		{"return;", 1, "return;"},
+		{"return", 1, "return;"},
+		{"return; return 5 * 2", 2, "return;return (5 * 2);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != tt.expectedCount {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d",
+				tt.expectedCount, len(program.Statements))
+		}
+
+		returnStmt := program.Statements[0].(*ast.ReturnStatement)
+		if returnStmt.ReturnValue != nil {
+			t.Errorf("returnStmt.ReturnValue not nil. got=%s", returnStmt.ReturnValue)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+		{"let z = 838383", "z", 838383},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
+			return
+		}
+
+		val := stmt.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, val, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestLetStatementExpressionValue(t *testing.T) {
+	input := `
+	let x = 5 * y;
+	let y = -a + b
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	first := program.Statements[0].(*ast.LetStatement)
+	if !testInfixExpression(t, first.Value, 5, "*", "y") {
+		return
+	}
+
+	if program.String() != "let x = (5 * y);let y = ((-a) + b);" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
+
+	if s.TokenLiteral() != "let" {
+		t.Errorf("s.TokenLiteral is not 'let', got=%q", s.TokenLiteral())
+	}
+
+	letStmt, ok := s.(*ast.LetStatement)
+	if !ok {
+		t.Errorf("s not *ast.LetStatement. got=%T", s)
+		return false
+	}
+
+	if letStmt.Name.Value != name {
+		t.Errorf("letStmt.Name.Value is not '%s'. got=%s", name, letStmt.Name.Value)
+		return false
+	}
+
+	if letStmt.Name.TokenLiteral() != name {
+		t.Errorf("letStmt.Name.TokenLiteral not '%s', got=%s",
+			name, letStmt.Name.TokenLiteral())
+		return false
+	}
+	return true
+}
+
+func TestParsingWithComments(t *testing.T) {
+	input := `// adds two numbers
+	let add = fn(a, b) { /* body */ a + b }; // trailing
+	add(1, /* two */ 2)`
+
+	for _, opts := range [][]lexer.Option{nil, {lexer.WithComments()}} {
+		l := lexer.New(input, opts...)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		expected := "let add = fn(a, b) (a + b);add(1, 2)"
+		if program.String() != expected {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     []lexer.Option
+		expected string
+	}{
+		{"let = 5;", nil, "1:5: expected a IDENT token, but got ="},
+		{"let x = 5;\nlet y 10;", nil, "2:7: expected a = token, but got INT"},
+		{"let x = 5;\n\t* 5", nil, "2:2: no prefix parse function for * found"},
+		{"let x 5;", []lexer.Option{lexer.WithFilename("main.mk")}, "main.mk:1:7: expected a = token, but got INT"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input, tt.opts...)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected errors for %q", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	l := lexer.New("let x 5;\n* 2;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) < 2 {
+		t.Fatalf("expected at least 2 errors. got=%d (%v)", len(errors), errors)
+	}
+
+	first := errors[0]
+	if first.Code != ErrUnexpectedToken {
+		t.Errorf("first.Code wrong. expected=%s, got=%s", ErrUnexpectedToken, first.Code)
+	}
+	if len(first.Expected) != 1 || first.Expected[0] != token.ASSIGN {
+		t.Errorf("first.Expected wrong. got=%v", first.Expected)
+	}
+	if first.Actual.Type != token.INT || first.Actual.Literal != "5" {
+		t.Errorf("first.Actual wrong. got=%+v", first.Actual)
+	}
+	if first.Pos.Line != 1 || first.Pos.Column != 7 {
+		t.Errorf("first.Pos wrong. got=%s", first.Pos)
+	}
+
+	var second *ParseError
+	for _, e := range errors[1:] {
+		if e.Code == ErrNoPrefixParseFn {
+			second = e
+			break
+		}
+	}
+	if second == nil {
+		t.Fatalf("no %s error in %v", ErrNoPrefixParseFn, errors)
+	}
+	if second.Actual.Type != token.ASTERISK || second.Pos.Line != 2 {
+		t.Errorf("second error wrong. got=%+v", second)
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	var list ErrorList
+	if list.Err() != nil {
+		t.Errorf("empty list Err() not nil. got=%v", list.Err())
+	}
+
+	list = append(list, &ParseError{Pos: token.Position{Line: 1, Column: 2}, Msg: "first"})
+	if list.Error() != "1:2: first" {
+		t.Errorf("list.Error() wrong. got=%q", list.Error())
+	}
+
+	list = append(list, &ParseError{Pos: token.Position{Line: 3, Column: 4}, Msg: "second"})
+	if list.Error() != "1:2: first (and 1 more errors)" {
+		t.Errorf("list.Error() wrong. got=%q", list.Error())
+	}
+
+	var err error = list.Err()
+	if err == nil {
+		t.Fatalf("list.Err() is nil")
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string
+	}{
+		{
+			"let x 5; let y = 10;",
+			[]string{"1:7: expected a = token, but got INT"},
+			"let y = 10;",
+		},
+		{
+			"let x = * 5 + ; let y = 10; y",
+			[]string{"1:9: no prefix parse function for * found"},
+			"let y = 10;y",
+		},
+		{
+			"let = 1\nlet y = 2\nreturn )\nreturn y;",
+			[]string{
+				"1:5: expected a IDENT token, but got =",
+				"3:8: no prefix parse function for ) found",
+			},
+			"let y = 2;return y;",
+		},
+		{
+			"if (x { y } let z = 3;",
+			[]string{"1:7: expected a ) token, but got {"},
+			"let z = 3;",
+		},
+		{
+			"add(1, 2; 5",
+			[]string{"1:9: expected a ) token, but got ;"},
+			"5",
+		},
+		{
+			"let f = fn(x) { x + }; let y = 2;",
+			[]string{"1:21: no prefix parse function for } found"},
+			"let y = 2;",
+		},
+		{
+			"let a = if (x) { 1 + }; let b = 2;",
+			[]string{"1:22: no prefix parse function for } found"},
+			"let b = 2;",
+		},
+		{
+			"let f = fn() { let = 1; x; if (y) { z } }\nlet g = 3;",
+			[]string{"1:20: expected a IDENT token, but got ="},
+			"let g = 3;",
+		},
+		{
+			"let h = {1 2}; let c = 1;",
+			[]string{"1:12: expected a : token, but got INT"},
+			"let c = 1;",
+		},
+		{
+			"let h = {1: 2 +}; [1, ) ; let c = 1;",
+			[]string{
+				"1:16: no prefix parse function for } found",
+				"1:23: no prefix parse function for ) found",
+			},
+			"let c = 1;",
+		},
+		{
+			"fn() { 1 +\nlet x = 1;",
+			[]string{"2:1: no prefix parse function for LET found"},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%v)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+
+		for i, msg := range tt.expectedErrors {
+			if errors[i].Error() != msg {
+				t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i].Error())
+			}
+		}
+
+		if program.String() != tt.expectedStatements {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.expectedStatements, program.String())
+		}
 	}
-	return true
 }
 
 func checkParserErrors(t *testing.T, p *Parser) {
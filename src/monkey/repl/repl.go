@@ -3,8 +3,11 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"monkey/evaluator"
 	"monkey/lexer"
-	"monkey/token"
+	"monkey/object"
+	"monkey/parser"
 	"os"
 	"strings"
 )
@@ -12,16 +15,56 @@ import (
 // Start function for using REPL
 func Start() {
 	reader := bufio.NewReader(os.Stdin)
+	env := object.NewEnvironment()
 	for {
 		fmt.Print("~> ")
-		text, _ := reader.ReadString('\n')
+		text, err := reader.ReadString('\n')
+		if err == io.EOF && text == "" {
+			return
+		}
 		text = strings.Replace(text, "\n", "", -1)
 		if strings.Compare("exit", text) == 0 {
 			os.Exit(0)
 		}
 		l := lexer.New(text)
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			printParserErrors(os.Stdout, p.Errors())
+			continue
+		}
+		evaluated := evaluator.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			fmt.Println(err.Trace())
+		} else if evaluated != nil {
+			fmt.Println(evaluated.Inspect())
 		}
 	}
 }
+
+// Run parses and evaluates a whole script read from r, writing errors to out
+func Run(filename string, r io.Reader, out io.Writer) bool {
+	l := lexer.NewReader(r, lexer.WithFilename(filename))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if err := l.Err(); err != nil {
+		fmt.Fprintln(out, err)
+		return false
+	}
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return false
+	}
+	evaluated := evaluator.Eval(program, object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(out, err.Trace())
+		return false
+	}
+	return true
+}
+
+func printParserErrors(out io.Writer, errors parser.ErrorList) {
+	for _, err := range errors {
+		fmt.Fprintf(out, "\t%s\n", err)
+	}
+}
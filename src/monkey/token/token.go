@@ -1,10 +1,46 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
-	Type TokenType
+	Type    TokenType
 	Literal string
+	Pos     Position
+
+	// BadEscape is the first invalid escape sequence of an ILLEGAL string
+	// literal, such as \q, and BadEscapePos is where it starts
+	BadEscape    string
+	BadEscapePos Position
+}
+
+// Position of a token in the source
+type Position struct {
+	Filename string // optional, empty when lexing a plain string
+	Offset   int    // byte offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number in runes, starting at 1
+}
+
+// IsValid reports whether the position has a line number
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as file:line:column, line:column or -
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
 }
 
 const (
@@ -16,53 +52,75 @@ const (
 	IDENT = "IDENT"
 	// INT for integer
 	INT = "INT"
+	// FLOAT for floating point numbers
+	FLOAT = "FLOAT"
+	// STRING for double quoted string literals
+	STRING = "STRING"
+	// COMMENT for // and /* */ comments, only emitted on request
+	COMMENT = "COMMENT"
 
 	// Operators
-	ASSIGN = "="
-	PLUS = "+"
-	BANG = "!"
-	MINUS = "-"
-	SLASH = "/"
+	ASSIGN   = "="
+	PLUS     = "+"
+	BANG     = "!"
+	MINUS    = "-"
+	SLASH    = "/"
 	ASTERISK = "*"
+	PERCENT  = "%"
+	POWER    = "**"
 
-	EQ = "=="
+	BIT_AND = "&"
+	BIT_OR  = "|"
+	BIT_XOR = "^"
+	BIT_NOT = "~"
+	LSHIFT  = "<<"
+	RSHIFT  = ">>"
+
+	EQ     = "=="
 	NOT_EQ = "!="
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
+	AND   = "&&"
+	OR    = "||"
 
 	// Delimeters
 	SEMICOLON = ";"
-	COMMA = ","
+	COMMA     = ","
+	COLON     = ":"
 
-	LPAREN = "("
-	RPAREN = ")"
-	LBRACE = "{"
-	RBRACE = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
 
 	// Keywords
 	FUNCTION = "FUNCTION"
-	LET = "LET"
-	IF = "IF"
-	ELSE = "ELSE"
-	RETURN = "RETURN"
-	TRUE = "TRUE"
-	FALSE = "FALSE"
+	LET      = "LET"
+	IF       = "IF"
+	ELSE     = "ELSE"
+	RETURN   = "RETURN"
+	TRUE     = "TRUE"
+	FALSE    = "FALSE"
 )
 
 var keywords = map[string]TokenType{
-	"fn":  FUNCTION,
-	"let": LET,
-	"if": IF,
-	"else": ELSE,
+	"fn":     FUNCTION,
+	"let":    LET,
+	"if":     IF,
+	"else":   ELSE,
 	"return": RETURN,
-	"true": TRUE,
-	"false": FALSE,
-  }
+	"true":   TRUE,
+	"false":  FALSE,
+}
 
 func LookupIdentifier(ident string) TokenType {
 	if tok, ok := keywords[ident]; ok {
 		return tok
 	}
 	return IDENT
-}
\ No newline at end of file
+}
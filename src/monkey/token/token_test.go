@@ -18,3 +18,21 @@ func TestTokenTypeLookup(t *testing.T){
 		t.Fatalf("expected IDENT")
 	}
 }
+
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		pos      Position
+		expected string
+	}{
+		{Position{}, "-"},
+		{Position{Filename: "main.mk"}, "main.mk"},
+		{Position{Line: 3, Column: 7}, "3:7"},
+		{Position{Filename: "main.mk", Offset: 20, Line: 3, Column: 7}, "main.mk:3:7"},
+	}
+
+	for _, tt := range tests {
+		if tt.pos.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, tt.pos.String())
+		}
+	}
+}